@@ -0,0 +1,34 @@
+// Package arrow writes rows of parquetlogger as Apache Arrow IPC. It is a
+// separate module, so that users of the adapters do not depend on Arrow.
+package arrow
+
+import (
+	"io"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+)
+
+// Format is an Arrow IPC format.
+type Format int
+
+const (
+	// FormatStream is the Apache Arrow IPC streaming format.
+	FormatStream Format = iota
+	// FormatFile is the Apache Arrow IPC file format, also known as
+	// Feather V2.
+	FormatFile
+)
+
+// recordWriter is implemented by ipc.Writer and ipc.FileWriter.
+type recordWriter interface {
+	Write(rec arrow.Record) error
+	Close() error
+}
+
+func newRecordWriter(w io.Writer, schema *arrow.Schema, format Format) (recordWriter, error) {
+	if format == FormatFile {
+		return ipc.NewFileWriter(w, ipc.WithSchema(schema))
+	}
+	return ipc.NewWriter(w, ipc.WithSchema(schema)), nil
+}
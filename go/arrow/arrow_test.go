@@ -0,0 +1,55 @@
+package arrow
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+)
+
+// bufferCloser is a destination which records Close.
+type bufferCloser struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *bufferCloser) Close() error {
+	b.closed = true
+	return nil
+}
+
+func readRecords(t *testing.T, buf []byte, format Format) (*arrow.Schema, []arrow.Record) {
+	t.Helper()
+	var records []arrow.Record
+	if format == FormatFile {
+		r, err := ipc.NewFileReader(bytes.NewReader(buf))
+		if err != nil {
+			t.Fatalf("Failed to read Arrow file: %v", err)
+		}
+		defer r.Close()
+		for i := 0; i < r.NumRecords(); i++ {
+			rec, err := r.Record(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec.Retain()
+			records = append(records, rec)
+		}
+		return r.Schema(), records
+	}
+	r, err := ipc.NewReader(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatalf("Failed to read Arrow stream: %v", err)
+	}
+	defer r.Release()
+	for r.Next() {
+		rec := r.Record()
+		rec.Retain()
+		records = append(records, rec)
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return r.Schema(), records
+}
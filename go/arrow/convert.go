@@ -0,0 +1,125 @@
+package arrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// convertBatchRows is the number of rows in a record batch of Converter.
+const convertBatchRows = 64 * 1024
+
+// Converter converts a parquet file written into it, such as by ExportTo of
+// a Logger, into Arrow on Close. It can also be returned by a WriterFactory
+// to rotate into Arrow files.
+type Converter struct {
+	out    io.Writer
+	format Format
+	f      *os.File
+	err    error
+}
+
+// NewConverter returns a Converter which writes Arrow into out. out is
+// closed with the Converter if it is an io.Closer.
+func NewConverter(out io.Writer, format Format) *Converter {
+	return &Converter{out: out, format: format}
+}
+
+// Write buffers the parquet file in an unlinked tempfile.
+func (c *Converter) Write(p []byte) (int, error) {
+	if c.f == nil && c.err == nil {
+		c.f, c.err = os.CreateTemp("", ".parquet-logger-*.parquet")
+		if c.err == nil {
+			os.Remove(c.f.Name())
+		}
+	}
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.f.Write(p)
+}
+
+// Close converts the parquet file into Arrow and closes the destination.
+func (c *Converter) Close() error {
+	err := c.convert()
+	if c.f != nil {
+		c.f.Close()
+	}
+	if closer, ok := c.out.(io.Closer); ok {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Abort discards the parquet file. The destination is aborted if it has an
+// Abort() error method, or closed.
+func (c *Converter) Abort() error {
+	if c.f != nil {
+		c.f.Close()
+	}
+	if a, ok := c.out.(interface{ Abort() error }); ok {
+		return a.Abort()
+	}
+	if closer, ok := c.out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (c *Converter) convert() error {
+	if c.err != nil {
+		return c.err
+	}
+	if c.f == nil {
+		return errors.New("No parquet file is written")
+	}
+	st, err := c.f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := convert(c.out, c.f, st.Size(), c.format); err != nil {
+		return fmt.Errorf("Failed to convert parquet to Arrow: %w", err)
+	}
+	return nil
+}
+
+// convert reads a parquet file and writes it as Arrow records.
+func convert(dst io.Writer, src io.ReaderAt, size int64, format Format) error {
+	pf, err := file.NewParquetReader(io.NewSectionReader(src, 0, size))
+	if err != nil {
+		return err
+	}
+	defer pf.Close()
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{BatchSize: convertBatchRows}, memory.DefaultAllocator)
+	if err != nil {
+		return err
+	}
+	rr, err := fr.GetRecordReader(context.Background(), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rr.Release()
+	w, err := newRecordWriter(dst, rr.Schema(), format)
+	if err != nil {
+		return err
+	}
+	for rr.Next() {
+		if err := w.Write(rr.Record()); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	if err := rr.Err(); err != nil && err != io.EOF {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,60 @@
+package arrow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/parquet-go/parquet-go"
+)
+
+type parquetRow struct {
+	StartTime time.Time
+	Method    string `parquet:",dict"`
+	Headers   map[string][]string
+}
+
+func TestConverter(t *testing.T) {
+	for _, format := range []Format{FormatStream, FormatFile} {
+		var out bufferCloser
+		c := NewConverter(&out, format)
+		rows := []parquetRow{
+			{StartTime: time.Now(), Method: "GET", Headers: map[string][]string{"Accept": {"*/*"}}},
+			{StartTime: time.Now(), Method: "POST"},
+		}
+		if err := parquet.Write(c, rows); err != nil {
+			t.Fatalf("Failed to write parquet: %v", err)
+		}
+		if err := c.Close(); err != nil {
+			t.Fatalf("Failed to convert: %v", err)
+		}
+		if !out.closed {
+			t.Error("Close did not close the destination")
+		}
+
+		schema, records := readRecords(t, out.Bytes(), format)
+		fields := parquet.SchemaOf(new(parquetRow)).Fields()
+		if len(schema.Fields()) != len(fields) {
+			t.Fatalf("got %d fields, want %d", len(schema.Fields()), len(fields))
+		}
+		for i, f := range fields {
+			if got := schema.Field(i).Name; got != f.Name() {
+				t.Errorf("field %d: got %s, want %s", i, got, f.Name())
+			}
+		}
+		if len(records) != 1 || records[0].NumRows() != 2 {
+			t.Fatalf("got %d records, want 1 record of 2 rows", len(records))
+		}
+		methods := records[0].Column(schema.FieldIndices("Method")[0]).(*array.String)
+		if methods.Value(0) != "GET" || methods.Value(1) != "POST" {
+			t.Errorf("got %v, want [GET POST]", methods)
+		}
+	}
+}
+
+func TestConverterEmpty(t *testing.T) {
+	var out bufferCloser
+	if err := NewConverter(&out, FormatStream).Close(); err == nil {
+		t.Error("Close without parquet succeeded")
+	}
+}
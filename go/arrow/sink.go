@@ -0,0 +1,275 @@
+package arrow
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/parquet-go/parquet-go"
+)
+
+// Sink writes rows as Arrow record batches. Its schema is converted from the
+// parquet schema of T as Converter does, so its records can be combined
+// with converted files, and the records are built from the fields of T
+// directly. Its methods are those of the Sink of the adapters, so it is
+// added by AddSink of a Logger of T.
+type Sink[T any] struct {
+	format  Format
+	out     io.Writer
+	schema  *arrow.Schema
+	columns []column
+	b       *array.RecordBuilder
+	rows    int
+	w       recordWriter
+}
+
+// NewSink returns a Sink which writes rows into out in format. Rows are
+// written as a record batch on every Flush. out is closed with the Sink if
+// it is an io.Closer. It panics if T has a field of an unsupported type.
+func NewSink[T any](out io.Writer, format Format) *Sink[T] {
+	schema, err := schemaOf[T]()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to convert the schema of %T: %v", *new(T), err))
+	}
+	columns := structColumns(reflect.TypeFor[T](), schema.Fields())
+	return &Sink[T]{
+		format:  format,
+		out:     out,
+		schema:  schema,
+		columns: columns,
+		b:       array.NewRecordBuilder(memory.DefaultAllocator, schema),
+	}
+}
+
+// Write implements Sink.
+func (s *Sink[T]) Write(rows []T) error {
+	for i := range rows {
+		v := reflect.ValueOf(&rows[i]).Elem()
+		for j, c := range s.columns {
+			c.append(s.b.Field(j), v.Field(c.index))
+		}
+	}
+	s.rows += len(rows)
+	return nil
+}
+
+// Flush writes rows written since the last Flush as a record batch.
+func (s *Sink[T]) Flush() error {
+	if s.w == nil {
+		w, err := newRecordWriter(s.out, s.schema, s.format)
+		if err != nil {
+			return err
+		}
+		s.w = w
+	}
+	if s.rows == 0 {
+		return nil
+	}
+	rec := s.b.NewRecord()
+	defer rec.Release()
+	s.rows = 0
+	return s.w.Write(rec)
+}
+
+// Close writes buffered rows and the end of the stream, and closes the
+// destination.
+func (s *Sink[T]) Close() error {
+	err := s.Flush()
+	if s.w != nil {
+		if cerr := s.w.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if c, ok := s.out.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	s.b.Release()
+	return err
+}
+
+// schemaOf returns the Arrow schema which Converter reads from a parquet
+// file of T. It is read from an empty file, so that both agree.
+func schemaOf[T any]() (*arrow.Schema, error) {
+	var buf bytes.Buffer
+	if err := parquet.NewGenericWriter[T](&buf).Close(); err != nil {
+		return nil, err
+	}
+	pf, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	defer pf.Close()
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		return nil, err
+	}
+	return fr.Schema()
+}
+
+// appendFunc appends v to b.
+type appendFunc func(b array.Builder, v reflect.Value)
+
+// column is a field of a struct and the function to append it.
+type column struct {
+	index  int
+	append appendFunc
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// structColumns returns the columns of t for fields, which are matched by
+// name. Names are taken from parquet tags as parquet-go does.
+func structColumns(t reflect.Type, fields []arrow.Field) []column {
+	index := make(map[string]int)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("parquet"); ok {
+			if tagName, _, _ := strings.Cut(tag, ","); tagName != "" {
+				name = tagName
+			}
+		}
+		index[name] = i
+	}
+	columns := make([]column, len(fields))
+	for i, field := range fields {
+		j, ok := index[field.Name]
+		if !ok {
+			panic(fmt.Sprintf("No field %s in %s", field.Name, t))
+		}
+		columns[i] = column{index: j, append: appendFuncOf(field.Type, field.Nullable, t.Field(j).Type)}
+	}
+	return columns
+}
+
+// appendFuncOf returns the function to append a value of t to a builder of
+// typ. A nil map or slice is appended as null if nullable, or empty as
+// parquet-go writes it.
+func appendFuncOf(typ arrow.DataType, nullable bool, t reflect.Type) appendFunc {
+	if t.Kind() == reflect.Pointer {
+		fn := appendFuncOf(typ, nullable, t.Elem())
+		return func(b array.Builder, v reflect.Value) {
+			if v.IsNil() {
+				b.AppendNull()
+			} else {
+				fn(b, v.Elem())
+			}
+		}
+	}
+	switch typ := typ.(type) {
+	case *arrow.TimestampType:
+		if t != timeType {
+			break
+		}
+		unit := typ.Unit.Multiplier()
+		return func(b array.Builder, v reflect.Value) {
+			b.(*array.TimestampBuilder).Append(arrow.Timestamp(v.Interface().(time.Time).UnixNano() / int64(unit)))
+		}
+	case *arrow.BooleanType:
+		return func(b array.Builder, v reflect.Value) {
+			b.(*array.BooleanBuilder).Append(v.Bool())
+		}
+	case *arrow.Int32Type:
+		return func(b array.Builder, v reflect.Value) {
+			b.(*array.Int32Builder).Append(int32(v.Int()))
+		}
+	case *arrow.Int64Type:
+		return func(b array.Builder, v reflect.Value) {
+			b.(*array.Int64Builder).Append(v.Int())
+		}
+	case *arrow.Uint32Type:
+		return func(b array.Builder, v reflect.Value) {
+			b.(*array.Uint32Builder).Append(uint32(v.Uint()))
+		}
+	case *arrow.Uint64Type:
+		return func(b array.Builder, v reflect.Value) {
+			b.(*array.Uint64Builder).Append(v.Uint())
+		}
+	case *arrow.Float32Type:
+		return func(b array.Builder, v reflect.Value) {
+			b.(*array.Float32Builder).Append(float32(v.Float()))
+		}
+	case *arrow.Float64Type:
+		return func(b array.Builder, v reflect.Value) {
+			b.(*array.Float64Builder).Append(v.Float())
+		}
+	case *arrow.StringType:
+		return func(b array.Builder, v reflect.Value) {
+			b.(*array.StringBuilder).Append(v.String())
+		}
+	case *arrow.BinaryType:
+		return func(b array.Builder, v reflect.Value) {
+			if nullable && v.IsNil() {
+				b.AppendNull()
+			} else {
+				b.(*array.BinaryBuilder).Append(v.Bytes())
+			}
+		}
+	case *arrow.ListType:
+		if t.Kind() != reflect.Slice {
+			break
+		}
+		elem := typ.ElemField()
+		fn := appendFuncOf(elem.Type, elem.Nullable, t.Elem())
+		return func(b array.Builder, v reflect.Value) {
+			if nullable && v.IsNil() {
+				b.AppendNull()
+				return
+			}
+			lb := b.(*array.ListBuilder)
+			lb.Append(true)
+			for i := range v.Len() {
+				fn(lb.ValueBuilder(), v.Index(i))
+			}
+		}
+	case *arrow.MapType:
+		if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String {
+			break
+		}
+		item := typ.ItemField()
+		fn := appendFuncOf(item.Type, item.Nullable, t.Elem())
+		return func(b array.Builder, v reflect.Value) {
+			if nullable && v.IsNil() {
+				b.AppendNull()
+				return
+			}
+			mb := b.(*array.MapBuilder)
+			mb.Append(true)
+			keys := v.MapKeys()
+			slices.SortFunc(keys, func(a, b reflect.Value) int {
+				return strings.Compare(a.String(), b.String())
+			})
+			for _, k := range keys {
+				mb.KeyBuilder().(*array.StringBuilder).Append(k.String())
+				fn(mb.ItemBuilder(), v.MapIndex(k))
+			}
+		}
+	case *arrow.StructType:
+		if t.Kind() != reflect.Struct {
+			break
+		}
+		columns := structColumns(t, typ.Fields())
+		return func(b array.Builder, v reflect.Value) {
+			sb := b.(*array.StructBuilder)
+			sb.Append(true)
+			for i, c := range columns {
+				c.append(sb.FieldBuilder(i), v.Field(c.index))
+			}
+		}
+	}
+	panic(fmt.Sprintf("Unsupported type %s for %s", t, typ))
+}
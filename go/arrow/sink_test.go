@@ -0,0 +1,158 @@
+package arrow
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/parquet-go/parquet-go"
+)
+
+type sinkRow struct {
+	StartTime time.Time
+	Latency   time.Duration
+	Method    string `parquet:",dict"`
+	Status    int
+	Headers   map[string][]string
+	Error     *string `parquet:"error"`
+	ignored   string
+}
+
+// rowType is the same as RowType of the adapters.
+type rowType struct {
+	StartTime       time.Time           `parquet:",delta"`
+	Latency         time.Duration       `parquet:",delta"`
+	Protocol        string              `parquet:",dict"`
+	Scheme          string              `parquet:",dict"`
+	RemoteAddr      string              `parquet:",dict"`
+	Host            string              `parquet:",dict"`
+	Method          string              `parquet:",dict"`
+	URL             string              `parquet:",dict"`
+	Pattern         string              `parquet:",dict"`
+	Status          int                 `parquet:",dict"`
+	RequestSize     int64               `parquet:",delta"`
+	ResponseSize    int64               `parquet:",delta"`
+	RequestHeaders  map[string][]string `parquet:","`
+	ResponseHeaders map[string][]string `parquet:","`
+	Error           *string             `parquet:","`
+	Instance        string              `parquet:",dict"`
+	Service         string              `parquet:",dict"`
+	Version         string              `parquet:",dict"`
+	Environment     string              `parquet:",dict"`
+	Goroutines      *int64              `parquet:","`
+	HeapInUse       *int64              `parquet:","`
+	GCCycleStart    *int64              `parquet:","`
+	GCCycleEnd      *int64              `parquet:","`
+}
+
+func TestSink(t *testing.T) {
+	var out bufferCloser
+	s := NewSink[sinkRow](&out, FormatStream)
+	start := time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC)
+	errText := "broken"
+	if err := s.Write([]sinkRow{
+		{StartTime: start, Latency: time.Second, Method: "GET", Status: 200, Headers: map[string][]string{"Accept": {"*/*"}}},
+		{StartTime: start, Method: "POST", Status: 500, Error: &errText},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write([]sinkRow{{Method: "PUT"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !out.closed {
+		t.Error("Close did not close the destination")
+	}
+
+	schema, records := readRecords(t, out.Bytes(), FormatStream)
+	var names []string
+	for _, f := range schema.Fields() {
+		names = append(names, f.Name)
+	}
+	if want := "StartTime Latency Method Status Headers error"; strings.Join(names, " ") != want {
+		t.Errorf("got fields %v, want %s", names, want)
+	}
+	if len(records) != 2 || records[0].NumRows() != 2 || records[1].NumRows() != 1 {
+		t.Fatalf("got %d records, want records of 2 and 1 rows", len(records))
+	}
+	rec := records[0]
+	if got := rec.Column(0).(*array.Timestamp).Value(0); got != arrow.Timestamp(start.UnixNano()) {
+		t.Errorf("StartTime: got %v", got)
+	}
+	if got := rec.Column(1).(*array.Int64).Value(0); got != int64(time.Second) {
+		t.Errorf("Latency: got %v", got)
+	}
+	if got := rec.Column(3).(*array.Int64).Value(1); got != 500 {
+		t.Errorf("Status: got %v", got)
+	}
+	headers := rec.Column(4).(*array.Map)
+	if headers.IsNull(0) || headers.IsNull(1) {
+		t.Errorf("Headers: got nulls %v and %v, want empty maps", headers.IsNull(0), headers.IsNull(1))
+	}
+	if keys := headers.Keys().(*array.String); keys.Len() != 1 || keys.Value(0) != "Accept" {
+		t.Errorf("Headers: got keys %v", keys)
+	}
+	errors := rec.Column(5).(*array.String)
+	if !errors.IsNull(0) || errors.Value(1) != "broken" {
+		t.Errorf("error: got %v", errors)
+	}
+}
+
+// TestSinkSchema checks that Sink writes the same records as Converter.
+func TestSinkSchema(t *testing.T) {
+	n := int64(3)
+	errText := "broken"
+	rows := []rowType{
+		{
+			StartTime:      time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC),
+			Latency:        time.Second,
+			Method:         "GET",
+			Status:         200,
+			RequestHeaders: map[string][]string{"Accept": {"*/*", "text/html"}},
+			Goroutines:     &n,
+		},
+		{Method: "POST", Status: 500, Error: &errText},
+	}
+
+	var converted bufferCloser
+	c := NewConverter(&converted, FormatStream)
+	if err := parquet.Write(c, rows); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var sunk bufferCloser
+	s := NewSink[rowType](&sunk, FormatStream)
+	if err := s.Write(rows); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want, wantRecords := readRecords(t, converted.Bytes(), FormatStream)
+	got, gotRecords := readRecords(t, sunk.Bytes(), FormatStream)
+	if !got.Equal(want) {
+		t.Fatalf("got schema\n%s\nwant\n%s", got, want)
+	}
+	if len(gotRecords) != 1 || len(wantRecords) != 1 || !array.RecordEqual(gotRecords[0], wantRecords[0]) {
+		t.Errorf("got records\n%v\nwant\n%v", gotRecords, wantRecords)
+	}
+}
+
+func TestSinkUnsupportedType(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewSink did not panic")
+		}
+	}()
+	NewSink[struct{ C chan int }](&bufferCloser{}, FormatStream)
+}
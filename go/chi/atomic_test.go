@@ -0,0 +1,84 @@
+package chi
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "log.parquet")
+
+	pl := NewLogger(WithOverwrite(false))
+	defer pl.Close()
+
+	if err := pl.Export(filename); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	if err := pl.Export(filename); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Export to an existing file: got %v, want fs.ErrExist", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "log.parquet" {
+		t.Errorf("unexpected files: %v", entries)
+	}
+}
+
+func TestAtomicFileAbort(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "log.parquet")
+	if err := os.WriteFile(filename, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	af, err := createAtomic(filename, true)
+	if err != nil {
+		t.Fatalf("Failed to create: %v", err)
+	}
+	if _, err := af.Write([]byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+	if err := af.Abort(); err != nil {
+		t.Fatalf("Failed to abort: %v", err)
+	}
+	if buf, err := os.ReadFile(filename); err != nil || string(buf) != "old" {
+		t.Errorf("destination is modified: %q, %v", buf, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("tempfile is left: %v", entries)
+	}
+}
+
+func TestAtomicFileMode(t *testing.T) {
+	dir := t.TempDir()
+	af, err := createAtomic(filepath.Join(dir, "log.parquet"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := af.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(dir, "want.parquet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	got, err := os.Stat(filepath.Join(dir, "log.parquet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.Stat(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Mode() != want.Mode() {
+		t.Errorf("got mode %v, want %v as os.Create", got.Mode(), want.Mode())
+	}
+}
@@ -0,0 +1,241 @@
+package chi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Frames between CollectorSink and the collector of the parquetlogger
+// command are a type byte, the big endian uint32 length of the payload and
+// the payload. Every request is answered with collectorOK or collectorErr.
+const (
+	// collectorRows is a parquet file of rows.
+	collectorRows byte = 'R'
+	// collectorExport exports the collected rows to the path in the payload,
+	// which is relative to the rotate directory of the collector.
+	collectorExport byte = 'E'
+	// collectorRotate exports the collected rows into the rotate directory
+	// of the collector, which answers the path.
+	collectorRotate byte = 'T'
+	collectorOK     byte = 'K'
+	collectorErr    byte = '!'
+)
+
+// maxFrameSize bounds the payload of a frame. It must match the collector.
+const maxFrameSize = 64 << 20
+
+func writeFrame(w io.Writer, typ byte, payload []byte) error {
+	b := binary.BigEndian.AppendUint32([]byte{typ}, uint32(len(payload)))
+	_, err := w.Write(append(b, payload...))
+	return err
+}
+
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var h [5]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(h[1:])
+	if n > maxFrameSize {
+		return 0, nil, fmt.Errorf("Frame of %d bytes is too large", n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return h[0], payload, nil
+}
+
+// collectorError is an error answered by the collector.
+type collectorError string
+
+func (e collectorError) Error() string {
+	return "Collector: " + string(e)
+}
+
+// CollectorConfig defines where CollectorSink sends rows.
+type CollectorConfig struct {
+	// Network is "unix" or "tcp". The default is "unix".
+	Network string
+	// Address is the address of the collector. The default is
+	// parquetlogger.sock in os.TempDir().
+	Address string
+	// Timeout bounds connecting and sending rows. The default is 10
+	// seconds. Export and Rotate wait for the collector without a timeout.
+	Timeout time.Duration
+	// BatchConfig defines batching and reconnects. The default
+	// MaxBatchRows is 4096. Client is not used.
+	BatchConfig
+}
+
+// CollectorSink sends rows to the collector of the parquetlogger command,
+// which merges rows of several processes into one file. Rows are sent as
+// a parquet file on every Flush or MaxBatchRows rows in background, and the
+// connection is reopened when it fails.
+// While a CollectorSink is the only sink of a Logger, the Logger has no
+// tempfile, and Export, ExportTo and Rotate of the Logger return
+// ErrNoTempfile. Rows logged before AddSink are discarded then.
+type CollectorSink[T any] struct {
+	cfg    CollectorConfig
+	batch  BatchConfig
+	schema *parquet.Schema
+	buf    bytes.Buffer
+	rowFile[T]
+	q    *batchQueue[[]byte]
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewCollectorSink returns a Sink which sends rows to a collector.
+func NewCollectorSink[T any](cfg CollectorConfig) *CollectorSink[T] {
+	if cfg.Network == "" {
+		cfg.Network = "unix"
+	}
+	if cfg.Address == "" {
+		cfg.Address = filepath.Join(os.TempDir(), "parquetlogger.sock")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	cfg.setDefaults(4096)
+	s := &CollectorSink[T]{cfg: cfg, schema: parquet.SchemaOf(new(T))}
+	s.batch = cfg.BatchConfig
+	s.batch.MaxBatchRows = 1
+	s.q = newBatchQueue(&s.batch, func(files [][]byte) error {
+		return s.send(files[0])
+	})
+	s.reset()
+	return s
+}
+
+func (*CollectorSink[T]) collectsRows() {}
+
+func (s *CollectorSink[T]) reset() {
+	s.buf.Reset()
+	s.rowFile = newRowFile[T](&config{}, s.schema, &s.buf)
+}
+
+// Write implements Sink.
+func (s *CollectorSink[T]) Write(rows []T) error {
+	if _, err := s.write(rows); err != nil {
+		return err
+	}
+	if s.stats.rows >= int64(s.cfg.MaxBatchRows) {
+		return s.Flush()
+	}
+	return nil
+}
+
+// Flush queues the buffered rows to be sent.
+func (s *CollectorSink[T]) Flush() error {
+	if s.stats.rows == 0 {
+		return nil
+	}
+	n := s.stats.rows
+	err := s.rowFile.w.Close()
+	file := bytes.Clone(s.buf.Bytes())
+	s.reset()
+	if err != nil {
+		return fmt.Errorf("Failed to write %d rows: %w", n, err)
+	}
+	if err := s.q.add(file); err != nil {
+		return fmt.Errorf("Failed to send %d rows: %w", n, err)
+	}
+	return nil
+}
+
+// Close sends the buffered rows, waits for queued rows and closes the
+// connection.
+func (s *CollectorSink[T]) Close() error {
+	err := s.Flush()
+	if qerr := s.q.close(); err == nil {
+		err = qerr
+	}
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	return err
+}
+
+func (s *CollectorSink[T]) send(file []byte) error {
+	err := s.cfg.retry(func() (time.Duration, error) {
+		if s.conn == nil {
+			conn, err := net.DialTimeout(s.cfg.Network, s.cfg.Address, s.cfg.Timeout)
+			if err != nil {
+				return 0, err
+			}
+			s.conn, s.r = conn, bufio.NewReader(conn)
+		}
+		if err := s.conn.SetDeadline(time.Now().Add(s.cfg.Timeout)); err != nil {
+			return 0, err
+		}
+		_, err := collectorCall(s.conn, s.r, collectorRows, file)
+		var cerr collectorError
+		if errors.As(err, &cerr) {
+			return -1, err
+		}
+		if err != nil {
+			s.conn.Close()
+			s.conn = nil
+			return 0, err
+		}
+		return 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to send rows to %s: %w", s.cfg.Address, err)
+	}
+	return nil
+}
+
+// Export makes the collector export rows collected from all processes to
+// filename in its rotate directory. filename is relative to the rotate
+// directory, and the collector refuses a path outside it. Rows which are
+// not sent yet are not included.
+func (s *CollectorSink[T]) Export(filename string) error {
+	_, err := s.cfg.command(collectorExport, []byte(filename))
+	return err
+}
+
+// Rotate makes the collector export rows collected from all processes into
+// its rotate directory, and returns the path of the file.
+func (s *CollectorSink[T]) Rotate() (string, error) {
+	path, err := s.cfg.command(collectorRotate, nil)
+	return string(path), err
+}
+
+// command sends a request over a new connection.
+func (cfg *CollectorConfig) command(typ byte, payload []byte) ([]byte, error) {
+	conn, err := net.DialTimeout(cfg.Network, cfg.Address, cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return collectorCall(conn, conn, typ, payload)
+}
+
+// collectorCall sends a request and returns the payload of the answer.
+func collectorCall(w io.Writer, r io.Reader, typ byte, payload []byte) ([]byte, error) {
+	if err := writeFrame(w, typ, payload); err != nil {
+		return nil, err
+	}
+	typ, resp, err := readFrame(r)
+	switch {
+	case err != nil:
+		return nil, err
+	case typ == collectorErr:
+		return nil, collectorError(resp)
+	case typ != collectorOK:
+		return nil, fmt.Errorf("Unexpected frame %q", typ)
+	}
+	return resp, nil
+}
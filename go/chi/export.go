@@ -0,0 +1,228 @@
+package chi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// A WriterFactory opens the destination of a rotated file.
+// name is a timestamped filename such as log-20060102-150405.000.parquet.
+// If the writer has an Abort() error method, it is called instead of Close
+// when the export fails.
+type WriterFactory func(ctx context.Context, name string) (io.WriteCloser, error)
+
+type exportRequest struct {
+	ctx context.Context
+	// name is the destination, or empty for the writer of ExportTo.
+	name string
+	open func() (io.WriteCloser, error)
+	// openPart opens a file of a partition if the rows are partitioned.
+	openPart func(name string) (io.WriteCloser, error)
+	// runtimeName and openRuntime are the companion file of
+	// WithRuntimeSampler.
+	runtimeName string
+	openRuntime func() (io.WriteCloser, error)
+	errCh       chan error
+}
+
+// dest describes the destination of req in messages.
+func (req *exportRequest) dest() string {
+	if req.name == "" {
+		return "writer"
+	}
+	return req.name
+}
+
+// Export exports parquet file. Rows collected so far are written into
+// filename and the Logger continues with an empty file.
+// The file is written into a sibling tempfile and renamed to filename, so
+// readers never see a partial file.
+// If the export fails, the rows are kept for the next export.
+// It returns ErrExportInProgress if another Export is running.
+func (pl *GenericLogger[T]) Export(filename string) error {
+	runtimeName := runtimeSampleName(filename)
+	return pl.exportWith(context.Background(), exportRequest{
+		name: filename,
+		open: func() (io.WriteCloser, error) {
+			return createAtomic(filename, pl.cfg.overwrite)
+		},
+		runtimeName: runtimeName,
+		openRuntime: func() (io.WriteCloser, error) {
+			return createAtomic(runtimeName, pl.cfg.overwrite)
+		},
+	})
+}
+
+// ExportTo writes rows collected so far into w as a parquet stream and the
+// Logger continues with an empty file.
+// w is not closed. If the export fails, e.g. because ctx is done, the rows
+// are kept for the next export.
+// It returns ErrExportInProgress if another Export is running.
+func (pl *GenericLogger[T]) ExportTo(ctx context.Context, w io.Writer) error {
+	return pl.exportWith(ctx, exportRequest{
+		open: func() (io.WriteCloser, error) {
+			return nopCloser{w}, nil
+		},
+	})
+}
+
+// Rotate exports parquet file with a timestamped name. The destination is
+// opened by the WriterFactory if it is set, or created in the rotate
+// directory. If a hive layout is set, rows are split into files per
+// partition instead.
+func (pl *GenericLogger[T]) Rotate() error {
+	ctx := context.Background()
+	open := func(name string) (io.WriteCloser, error) {
+		if factory := pl.cfg.writerFactory; factory != nil {
+			return factory(ctx, name)
+		}
+		filename := filepath.Join(pl.cfg.rotateDir, name)
+		pl.dirMu.Lock()
+		defer pl.dirMu.Unlock()
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			return nil, err
+		}
+		af, err := createAtomic(filename, pl.cfg.overwrite)
+		if err != nil {
+			return nil, err
+		}
+		af.onCommit = pl.cfg.onRotate
+		return af, nil
+	}
+	name := timestampedName("log.parquet", time.Now())
+	runtimeName := filepath.Join(runtimeSampleDir, name)
+	openRuntime := func() (io.WriteCloser, error) {
+		return open(runtimeName)
+	}
+	if len(pl.cfg.partitionKeys) > 0 {
+		return pl.exportWith(ctx, exportRequest{
+			name:        pl.cfg.rotateDir,
+			openPart:    open,
+			runtimeName: runtimeName,
+			openRuntime: openRuntime,
+		})
+	}
+	return pl.exportWith(ctx, exportRequest{
+		name: name,
+		open: func() (io.WriteCloser, error) {
+			return open(name)
+		},
+		runtimeName: runtimeName,
+		openRuntime: openRuntime,
+	})
+}
+
+func (pl *GenericLogger[T]) exportWith(ctx context.Context, req exportRequest) error {
+	if pl.ch == nil {
+		return ErrNotInitialized
+	}
+	if !pl.transition(stateExporting, stateStarting, stateRunning) {
+		pl.mu.Lock()
+		defer pl.mu.Unlock()
+		if pl.state == stateClosed {
+			return ErrClosed
+		}
+		return ErrExportInProgress
+	}
+	req.ctx = ctx
+	req.errCh = make(chan error, 1)
+	select {
+	case pl.exportCh <- req:
+	case <-pl.doneCh:
+		return ErrClosed
+	case <-ctx.Done():
+		pl.transition(stateRunning, stateExporting)
+		return ctx.Err()
+	}
+	err := <-req.errCh
+	if err == nil {
+		pl.triggerRetention()
+	}
+	return err
+}
+
+// export finishes tf and copies it into the destination of req. tf is
+// closed by the caller.
+func (pl *GenericLogger[T]) export(tf *tempfile[T], req exportRequest) (err error) {
+	f, w := tf.f, tf.w
+	dropped := pl.dropped.Swap(0)
+	defer func() {
+		if err != nil {
+			// The rows are kept for the next export, and so is the count of
+			// dropped rows.
+			pl.dropped.Add(dropped)
+		}
+	}()
+	setMetadata(w, pl.meta, tf.stats, dropped)
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("Failed to close parquet writer: %w", err)
+	}
+	if err := req.ctx.Err(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("Failed to sync tempfile: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("Failed to seek tempfile: %w", err)
+	}
+	if req.openPart != nil {
+		return pl.exportPartitions(req, f, dropped)
+	}
+	out, err := req.open()
+	if err != nil {
+		return fmt.Errorf("Failed to create %s: %w", req.dest(), err)
+	}
+	if _, err := io.Copy(ctxWriter{ctx: req.ctx, w: out}, f); err != nil {
+		abort(out)
+		return fmt.Errorf("Failed to copy from %s to %s: %w", f.Name(), req.dest(), err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("Failed to close %s: %w", req.dest(), err)
+	}
+	if req.name == "" {
+		pl.cfg.logger.Info("Succeed to export")
+	} else {
+		pl.cfg.logger.Info("Succeed to export", "filename", req.name)
+	}
+	return nil
+}
+
+// ctxWriter stops writing when ctx is done.
+type ctxWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (cw ctxWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cw.w.Write(p)
+}
+
+// aborter is implemented by writers which can discard a partial output.
+type aborter interface {
+	Abort() error
+}
+
+// abort discards out if it is an aborter, or closes it.
+func abort(out io.WriteCloser) {
+	if a, ok := out.(aborter); ok {
+		a.Abort()
+	} else {
+		out.Close()
+	}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
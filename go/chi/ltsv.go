@@ -0,0 +1,109 @@
+package chi
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An LTSVField is a label of LTSV and its value taken from a row.
+type LTSVField struct {
+	Label string
+	Value func(row *RowType) string
+}
+
+// DefaultLTSVFields returns the fields read by alp with its default labels:
+// time, host, method, uri, status, size, reqtime and ua.
+func DefaultLTSVFields() []LTSVField {
+	return []LTSVField{
+		{Label: "time", Value: func(row *RowType) string {
+			return row.StartTime.Format(time.RFC3339)
+		}},
+		{Label: "host", Value: func(row *RowType) string {
+			return row.RemoteAddr
+		}},
+		{Label: "method", Value: func(row *RowType) string {
+			return row.Method
+		}},
+		{Label: "uri", Value: func(row *RowType) string {
+			return row.URL
+		}},
+		{Label: "status", Value: func(row *RowType) string {
+			return strconv.Itoa(row.Status)
+		}},
+		{Label: "size", Value: func(row *RowType) string {
+			return strconv.FormatInt(row.ResponseSize, 10)
+		}},
+		{Label: "reqtime", Value: func(row *RowType) string {
+			return string(millis(row.Latency.Milliseconds()))
+		}},
+		{Label: "ua", Value: func(row *RowType) string {
+			if ua := row.RequestHeaders["User-Agent"]; len(ua) > 0 {
+				return ua[0]
+			}
+			return ""
+		}},
+	}
+}
+
+// ltsvEscaper escapes separators of LTSV in values.
+var ltsvEscaper = strings.NewReplacer("\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// LTSVSink writes rows as LTSV, which alp reads with "alp ltsv".
+type LTSVSink struct {
+	fields []LTSVField
+	out    io.Writer
+	bw     *bufio.Writer
+}
+
+var _ Sink[RowType] = (*LTSVSink)(nil)
+
+// NewLTSVSink returns a Sink which writes a line of fields per row into out.
+// The default fields are DefaultLTSVFields(). Empty values are written as
+// "-". out is closed with the Sink if it is an io.Closer.
+func NewLTSVSink(out io.Writer, fields []LTSVField) *LTSVSink {
+	if fields == nil {
+		fields = DefaultLTSVFields()
+	}
+	return &LTSVSink{fields: fields, out: out, bw: bufio.NewWriter(out)}
+}
+
+// Write implements Sink.
+func (s *LTSVSink) Write(rows []RowType) error {
+	for i := range rows {
+		for j, f := range s.fields {
+			if j > 0 {
+				s.bw.WriteByte('\t')
+			}
+			s.bw.WriteString(f.Label)
+			s.bw.WriteByte(':')
+			v := f.Value(&rows[i])
+			if v == "" {
+				v = "-"
+			}
+			ltsvEscaper.WriteString(s.bw, v)
+		}
+		if err := s.bw.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush implements Sink.
+func (s *LTSVSink) Flush() error {
+	return s.bw.Flush()
+}
+
+// Close flushes buffered lines and closes the destination.
+func (s *LTSVSink) Close() error {
+	err := s.bw.Flush()
+	if c, ok := s.out.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package chi
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+// modulePath is the import path of this module.
+var modulePath = reflect.TypeOf(RowType{}).PkgPath()
+
+// staticMetadata returns metadata which does not change while the process runs.
+func (pl *GenericLogger[T]) staticMetadata() map[string]string {
+	meta := make(map[string]string)
+	for k, v := range pl.cfg.metadata {
+		meta[k] = v
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		meta["hostname"] = hostname
+	}
+	meta["pid"] = strconv.Itoa(os.Getpid())
+	meta["go_version"] = runtime.Version()
+	meta["adapter"] = adapterName
+	meta["module_version"] = moduleVersion()
+	meta["config"] = pl.cfg.String()
+	return meta
+}
+
+// setMetadata sets meta and metadata describing a file of rows into w.
+func setMetadata[T any](w rowWriter[T], meta map[string]string, st fileStats, dropped int64) {
+	for k, v := range meta {
+		w.SetKeyValueMetadata(k, v)
+	}
+	if st.rows > 0 {
+		w.SetKeyValueMetadata("first_start_time", st.first.Format(time.RFC3339Nano))
+		w.SetKeyValueMetadata("last_start_time", st.last.Format(time.RFC3339Nano))
+	}
+	w.SetKeyValueMetadata("row_count", strconv.FormatInt(st.rows, 10))
+	w.SetKeyValueMetadata("dropped_count", strconv.FormatInt(dropped, 10))
+}
+
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
+
+// String returns the config as JSON.
+func (c *config) String() string {
+	buf, _ := json.Marshal(map[string]any{
+		"rotate_dir":      c.rotateDir,
+		"overwrite":       c.overwrite,
+		"writer_factory":  c.writerFactory != nil,
+		"labels":          c.labels,
+		"sort_rows":       c.sortRows,
+		"bloom_filters":   c.bloomFilters,
+		"retention":       c.retention,
+		"hooks_in_writer": c.hooksInWriter,
+		"runtime_stats":   c.runtimeStats,
+		"runtime_sampler": c.runtimeSampleInterval.String(),
+	})
+	return string(buf)
+}
@@ -0,0 +1,69 @@
+package chi
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics(MetricsConfig{Namespace: "app", Buckets: []float64{1, 0.1}})
+	pl := NewLogger()
+	pl.AddSink(m)
+	sendAndWait(pl,
+		RowType{Method: "GET", Pattern: "/user/{id}", Status: 200, Latency: 50 * time.Millisecond, ResponseSize: 100},
+		RowType{Method: "GET", Pattern: "/user/{id}", Status: 204, Latency: 500 * time.Millisecond, ResponseSize: 20},
+		RowType{Method: "POST", Pattern: `/"q"`, Status: 503, Latency: 2 * time.Second, RequestSize: 10},
+		RowType{Method: "PURGE", Pattern: "/", Status: 405, RequestSize: -1, ResponseSize: -1},
+	)
+	pl.drop(2)
+	if err := pl.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("got Content-Type %s", ct)
+	}
+	want := `# HELP app_http_requests_total Number of HTTP requests.
+# TYPE app_http_requests_total counter
+app_http_requests_total{method="GET",pattern="/user/{id}",status_class="2xx"} 2
+app_http_requests_total{method="POST",pattern="/\"q\"",status_class="5xx"} 1
+app_http_requests_total{method="_OTHER",pattern="/",status_class="4xx"} 1
+# HELP app_http_request_duration_seconds Latency of HTTP requests in seconds.
+# TYPE app_http_request_duration_seconds histogram
+app_http_request_duration_seconds_bucket{method="GET",pattern="/user/{id}",status_class="2xx",le="0.1"} 1
+app_http_request_duration_seconds_bucket{method="GET",pattern="/user/{id}",status_class="2xx",le="1"} 2
+app_http_request_duration_seconds_bucket{method="GET",pattern="/user/{id}",status_class="2xx",le="+Inf"} 2
+app_http_request_duration_seconds_sum{method="GET",pattern="/user/{id}",status_class="2xx"} 0.55
+app_http_request_duration_seconds_count{method="GET",pattern="/user/{id}",status_class="2xx"} 2
+app_http_request_duration_seconds_bucket{method="POST",pattern="/\"q\"",status_class="5xx",le="0.1"} 0
+app_http_request_duration_seconds_bucket{method="POST",pattern="/\"q\"",status_class="5xx",le="1"} 0
+app_http_request_duration_seconds_bucket{method="POST",pattern="/\"q\"",status_class="5xx",le="+Inf"} 1
+app_http_request_duration_seconds_sum{method="POST",pattern="/\"q\"",status_class="5xx"} 2
+app_http_request_duration_seconds_count{method="POST",pattern="/\"q\"",status_class="5xx"} 1
+app_http_request_duration_seconds_bucket{method="_OTHER",pattern="/",status_class="4xx",le="0.1"} 1
+app_http_request_duration_seconds_bucket{method="_OTHER",pattern="/",status_class="4xx",le="1"} 1
+app_http_request_duration_seconds_bucket{method="_OTHER",pattern="/",status_class="4xx",le="+Inf"} 1
+app_http_request_duration_seconds_sum{method="_OTHER",pattern="/",status_class="4xx"} 0
+app_http_request_duration_seconds_count{method="_OTHER",pattern="/",status_class="4xx"} 1
+# HELP app_http_request_size_bytes_total Total size of HTTP request bodies in bytes.
+# TYPE app_http_request_size_bytes_total counter
+app_http_request_size_bytes_total{method="GET",pattern="/user/{id}",status_class="2xx"} 0
+app_http_request_size_bytes_total{method="POST",pattern="/\"q\"",status_class="5xx"} 10
+app_http_request_size_bytes_total{method="_OTHER",pattern="/",status_class="4xx"} 0
+# HELP app_http_response_size_bytes_total Total size of HTTP response bodies in bytes.
+# TYPE app_http_response_size_bytes_total counter
+app_http_response_size_bytes_total{method="GET",pattern="/user/{id}",status_class="2xx"} 120
+app_http_response_size_bytes_total{method="POST",pattern="/\"q\"",status_class="5xx"} 0
+app_http_response_size_bytes_total{method="_OTHER",pattern="/",status_class="4xx"} 0
+# HELP app_dropped_rows_total Number of rows dropped by the Logger.
+# TYPE app_dropped_rows_total counter
+app_dropped_rows_total 2
+`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
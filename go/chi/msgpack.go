@@ -0,0 +1,213 @@
+package chi
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// The functions below implement the subset of MessagePack which the Fluent
+// Forward protocol needs.
+
+func appendMsgpackNil(b []byte) []byte {
+	return append(b, 0xc0)
+}
+
+func appendMsgpackInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n < 128, n >= -32 && n < 0:
+		return append(b, byte(n))
+	case n >= math.MinInt32 && n <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(n))
+	}
+}
+
+func appendMsgpackString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n < 1<<8:
+		b = append(b, 0xd9, byte(n))
+	case n < 1<<16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+func appendMsgpackArrayHeader(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x90|byte(n))
+	case n < 1<<16:
+		return binary.BigEndian.AppendUint16(append(b, 0xdc), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdd), uint32(n))
+	}
+}
+
+func appendMsgpackMapHeader(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x80|byte(n))
+	case n < 1<<16:
+		return binary.BigEndian.AppendUint16(append(b, 0xde), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdf), uint32(n))
+	}
+}
+
+// appendMsgpackEventTime appends t as EventTime of Fluentd, the extension
+// type 0 holding seconds and nanoseconds.
+func appendMsgpackEventTime(b []byte, t time.Time) []byte {
+	b = append(b, 0xd7, 0x00)
+	b = binary.BigEndian.AppendUint32(b, uint32(t.Unix()))
+	return binary.BigEndian.AppendUint32(b, uint32(t.Nanosecond()))
+}
+
+// msgpackExt is a value of an extension type.
+type msgpackExt struct {
+	Type int8
+	Data []byte
+}
+
+// readMsgpack reads a value. Maps are returned as map[string]any, arrays as
+// []any, integers as int64 and strings and binaries as string.
+func readMsgpack(r *bufio.Reader) (any, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	readN := func(n int) ([]byte, error) {
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		return b, err
+	}
+	readUint := func(n int) (uint64, error) {
+		b, err := readN(n)
+		if err != nil {
+			return 0, err
+		}
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		return v, nil
+	}
+	readString := func(n uint64, err error) (any, error) {
+		if err != nil {
+			return nil, err
+		}
+		b, err := readN(int(n))
+		return string(b), err
+	}
+	readArray := func(n uint64, err error) (any, error) {
+		if err != nil {
+			return nil, err
+		}
+		a := make([]any, n)
+		for i := range a {
+			if a[i], err = readMsgpack(r); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	}
+	readMap := func(n uint64, err error) (any, error) {
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]any, n)
+		for i := uint64(0); i < n; i++ {
+			k, err := readMsgpack(r)
+			if err != nil {
+				return nil, err
+			}
+			if m[fmt.Sprint(k)], err = readMsgpack(r); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	readExt := func(n uint64, err error) (any, error) {
+		if err != nil {
+			return nil, err
+		}
+		b, err := readN(int(n) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackExt{Type: int8(b[0]), Data: b[1:]}, nil
+	}
+	switch {
+	case c < 0x80:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return readMap(uint64(c&0x0f), nil)
+	case c&0xf0 == 0x90:
+		return readArray(uint64(c&0x0f), nil)
+	case c&0xe0 == 0xa0:
+		return readString(uint64(c&0x1f), nil)
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xd9:
+		return readString(readUint(1))
+	case 0xc5, 0xda:
+		return readString(readUint(2))
+	case 0xc6, 0xdb:
+		return readString(readUint(4))
+	case 0xc7:
+		return readExt(readUint(1))
+	case 0xc8:
+		return readExt(readUint(2))
+	case 0xc9:
+		return readExt(readUint(4))
+	case 0xca:
+		v, err := readUint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := readUint(8)
+		return math.Float64frombits(v), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := readUint(1 << (c - 0xcc))
+		return int64(v), err
+	case 0xd0:
+		v, err := readUint(1)
+		return int64(int8(v)), err
+	case 0xd1:
+		v, err := readUint(2)
+		return int64(int16(v)), err
+	case 0xd2:
+		v, err := readUint(4)
+		return int64(int32(v)), err
+	case 0xd3:
+		v, err := readUint(8)
+		return int64(v), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return readExt(1<<(c-0xd4), nil)
+	case 0xdc:
+		return readArray(readUint(2))
+	case 0xdd:
+		return readArray(readUint(4))
+	case 0xde:
+		return readMap(readUint(2))
+	case 0xdf:
+		return readMap(readUint(4))
+	}
+	return nil, errors.New("Unknown MessagePack format")
+}
@@ -0,0 +1,70 @@
+package chi
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	var rl rateLimiter
+	now := time.Now()
+	if ok, n := rl.allow("a", now); !ok || n != 0 {
+		t.Fatalf("first message: got %v, %d", ok, n)
+	}
+	for i := range 3 {
+		if ok, _ := rl.allow("a", now.Add(time.Duration(i)*time.Second)); ok {
+			t.Fatalf("repeated message %d was not suppressed", i)
+		}
+	}
+	if ok, _ := rl.allow("b", now); !ok {
+		t.Fatalf("other message was suppressed")
+	}
+	if ok, n := rl.allow("a", now.Add(reportInterval)); !ok || n != 3 {
+		t.Fatalf("message after interval: got %v, %d", ok, n)
+	}
+}
+
+func TestRateLimiterEviction(t *testing.T) {
+	var rl rateLimiter
+	now := time.Now()
+	rl.allow("old", now)
+	for i := range maxReportKeys - 1 {
+		rl.allow(fmt.Sprint(i), now.Add(time.Second))
+	}
+	// "old" is evicted to make room for "new", but the others are kept.
+	if ok, _ := rl.allow("new", now.Add(2*time.Second)); !ok {
+		t.Fatal("new message was suppressed")
+	}
+	if ok, _ := rl.allow("0", now.Add(2*time.Second)); ok {
+		t.Error("recent message was forgotten")
+	}
+	if ok, _ := rl.allow("old", now.Add(2*time.Second)); !ok {
+		t.Error("evicted message was suppressed")
+	}
+}
+
+func TestReportError(t *testing.T) {
+	var errs []error
+	// The writer is not started, so the channel is never drained.
+	pl := &Logger{
+		ch: make(chan RowType, 1),
+		cfg: config{
+			onError: func(err error) {
+				errs = append(errs, err)
+			},
+		},
+	}
+	for range 3 {
+		pl.send(RowType{})
+	}
+	// The second ErrChannelFull is suppressed by the rate limiter.
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1", len(errs))
+	}
+	for _, err := range errs {
+		if err != ErrChannelFull {
+			t.Errorf("got %v, want ErrChannelFull", err)
+		}
+	}
+}
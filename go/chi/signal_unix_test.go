@@ -0,0 +1,53 @@
+//go:build unix
+
+package chi
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHandleSignals(t *testing.T) {
+	exportDir := t.TempDir()
+	rotateDir := t.TempDir()
+
+	pl := NewLogger(WithRotateDir(rotateDir))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pl.HandleSignals(ctx, SignalConfig{
+		Path: filepath.Join(exportDir, "log.parquet"),
+		OnError: func(err error) {
+			t.Errorf("Failed to handle signal: %v", err)
+		},
+	})
+
+	for _, tt := range []struct {
+		sig syscall.Signal
+		dir string
+	}{
+		{syscall.SIGUSR1, exportDir},
+		{syscall.SIGHUP, rotateDir},
+	} {
+		pl.send(RowType{StartTime: time.Now()})
+		if err := syscall.Kill(os.Getpid(), tt.sig); err != nil {
+			t.Fatalf("Failed to send %v: %v", tt.sig, err)
+		}
+		if !waitForParquet(tt.dir) {
+			t.Fatalf("No parquet file in %s after %v", tt.dir, tt.sig)
+		}
+	}
+}
+
+func waitForParquet(dir string) bool {
+	for range 50 {
+		if files, _ := filepath.Glob(filepath.Join(dir, "log-*.parquet")); len(files) > 0 {
+			return true
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package chi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+type recordSink struct {
+	rows    []RowType
+	flushed int
+	closed  bool
+	err     error
+}
+
+func (s *recordSink) Write(rows []RowType) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.rows = append(s.rows, rows...)
+	return nil
+}
+
+func (s *recordSink) Flush() error {
+	s.flushed++
+	return nil
+}
+
+func (s *recordSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestSinks(t *testing.T) {
+	failing := &recordSink{err: errors.New("broken")}
+	record := &recordSink{}
+	out := &bufferCloser{}
+	errCh := make(chan error, 10)
+	pl := NewLogger(
+		WithFlushInterval(time.Millisecond),
+		WithOnError(func(err error) {
+			select {
+			case errCh <- err:
+			default:
+			}
+		}),
+	)
+	for _, sink := range []Sink[RowType]{failing, record, NewParquetSink[RowType](out)} {
+		if err := pl.AddSink(sink); err != nil {
+			t.Fatalf("Failed to add a sink: %v", err)
+		}
+	}
+
+	sendAndWait(pl, RowType{Method: "GET"}, RowType{Method: "POST"})
+	var buf bytes.Buffer
+	if err := pl.ExportTo(context.Background(), &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	if n := countRows(t, buf.Bytes()); n != 2 {
+		t.Errorf("Export: got %d rows, want 2", n)
+	}
+	time.Sleep(10 * time.Millisecond)
+	pl.Close()
+
+	if err := <-errCh; !errors.Is(err, failing.err) {
+		t.Errorf("got %v, want an error of the failing sink", err)
+	}
+	if len(record.rows) != 2 || record.flushed == 0 || !record.closed {
+		t.Errorf("got %d rows, %d flushes and closed %v", len(record.rows), record.flushed, record.closed)
+	}
+	if !out.closed {
+		t.Error("ParquetSink did not close the destination")
+	}
+	if n := countRows(t, out.Bytes()); n != 2 {
+		t.Errorf("ParquetSink: got %d rows, want 2", n)
+	}
+}
+
+func countRows(t *testing.T, buf []byte) int {
+	t.Helper()
+	rows, err := parquet.Read[RowType](bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		t.Fatalf("Failed to read parquet: %v", err)
+	}
+	return len(rows)
+}
+
+func TestAddSinkClosed(t *testing.T) {
+	pl := NewLogger()
+	pl.Close()
+	if err := pl.AddSink(&recordSink{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("got %v, want ErrClosed", err)
+	}
+}
+
+func TestWithoutTempfile(t *testing.T) {
+	errCh := make(chan error, 10)
+	pl := NewLogger(WithoutTempfile(), WithOnError(func(err error) { errCh <- err }))
+	record := &recordSink{}
+	pl.AddSink(record)
+
+	sendAndWait(pl, RowType{Method: "GET"})
+	if err := pl.ExportTo(context.Background(), io.Discard); !errors.Is(err, ErrNoTempfile) {
+		t.Errorf("got %v, want ErrNoTempfile", err)
+	}
+	pl.Close()
+	if len(record.rows) != 1 {
+		t.Errorf("got %d rows, want 1", len(record.rows))
+	}
+	if len(errCh) > 0 {
+		t.Errorf("got error %v", <-errCh)
+	}
+}
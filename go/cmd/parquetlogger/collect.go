@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// Frames are a type byte, the big endian uint32 length of the payload and
+// the payload. They must match CollectorSink of the middlewares.
+const (
+	frameRows   byte = 'R'
+	frameExport byte = 'E'
+	frameRotate byte = 'T'
+	frameOK     byte = 'K'
+	frameErr    byte = '!'
+)
+
+// maxFrameSize bounds the payload of a frame, so that a client cannot make
+// the collector allocate much memory.
+const maxFrameSize = 64 << 20
+
+func writeFrame(w io.Writer, typ byte, payload []byte) error {
+	b := binary.BigEndian.AppendUint32([]byte{typ}, uint32(len(payload)))
+	_, err := w.Write(append(b, payload...))
+	return err
+}
+
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var h [5]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(h[1:])
+	if n > maxFrameSize {
+		return 0, nil, fmt.Errorf("Frame of %d bytes is too large", n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return h[0], payload, nil
+}
+
+func runCollect(args []string) error {
+	fs := flag.NewFlagSet("collect", flag.ExitOnError)
+	network := fs.String("network", "unix", "`network` to listen on, unix or tcp")
+	addr := fs.String("addr", filepath.Join(os.TempDir(), "parquetlogger.sock"), "`address` to listen on")
+	c := &collector{}
+	fs.StringVar(&c.dir, "rotate-dir", os.TempDir(), "`directory` where rotate writes files")
+	fs.StringVar(&c.sortBy, "sort-by", "StartTime", "`column` to sort rows by, empty to keep the order")
+	fs.Int64Var(&c.rowGroupRows, "row-group-rows", 1000000, "number of `rows` in a row group")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s collect [options]\n       %s collect [options] export out.parquet\n       %s collect [options] rotate\nThe export path is relative to -rotate-dir and must be in it.\n", os.Args[0], os.Args[0], os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	switch fs.Arg(0) {
+	case "":
+		return c.listenAndServe(*network, *addr)
+	case "export":
+		if fs.NArg() != 2 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		_, err := command(*network, *addr, frameExport, []byte(fs.Arg(1)))
+		return err
+	case "rotate":
+		path, err := command(*network, *addr, frameRotate, nil)
+		if err == nil {
+			fmt.Println(string(path))
+		}
+		return err
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	return nil
+}
+
+// command sends a request to a running collector.
+func command(network, addr string, typ byte, payload []byte) ([]byte, error) {
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := writeFrame(conn, typ, payload); err != nil {
+		return nil, err
+	}
+	typ, resp, err := readFrame(conn)
+	switch {
+	case err != nil:
+		return nil, err
+	case typ == frameErr:
+		return nil, errors.New(string(resp))
+	case typ != frameOK:
+		return nil, fmt.Errorf("Unexpected frame %q", typ)
+	}
+	return resp, nil
+}
+
+// collector merges rows sent by several processes into one file.
+type collector struct {
+	dir          string
+	sortBy       string
+	rowGroupRows int64
+
+	mu sync.Mutex
+	f  *os.File
+	w  *parquet.Writer
+	// pending are files of collected rows which are not exported yet,
+	// since an export failed.
+	pending []string
+	schema  *parquet.Schema
+}
+
+// listenAndServe serves until SIGINT or SIGTERM, and rotates on SIGHUP and
+// before exiting.
+func (c *collector) listenAndServe(network, addr string) error {
+	if network == "unix" {
+		if st, err := os.Lstat(addr); err == nil && st.Mode()&os.ModeSocket != 0 {
+			os.Remove(addr)
+		}
+	}
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	go func() {
+		for s := range sig {
+			if _, err := c.rotate(); err != nil && !errors.Is(err, errNoRows) {
+				slog.Error(err.Error())
+			}
+			if s != syscall.SIGHUP {
+				ln.Close()
+				return
+			}
+		}
+	}()
+	err = c.serve(ln)
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+func (c *collector) serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go c.handle(conn)
+	}
+}
+
+func (c *collector) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		typ, payload, err := readFrame(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				slog.Error(fmt.Sprintf("Failed to read from %s: %v", conn.RemoteAddr(), err))
+			}
+			return
+		}
+		var resp []byte
+		switch typ {
+		case frameRows:
+			err = c.add(payload)
+		case frameExport:
+			var filename string
+			if filename, err = c.exportPath(string(payload)); err == nil {
+				err = c.export(filename)
+			}
+		case frameRotate:
+			var path string
+			path, err = c.rotate()
+			resp = []byte(path)
+		default:
+			err = fmt.Errorf("Unknown frame %q", typ)
+		}
+		if err != nil {
+			err = writeFrame(conn, frameErr, []byte(err.Error()))
+		} else {
+			err = writeFrame(conn, frameOK, resp)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// add appends rows of a parquet file to the collected rows. The first file
+// defines the schema.
+func (c *collector) add(file []byte) error {
+	pf, err := parquet.OpenFile(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		return fmt.Errorf("Failed to open rows: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.schema == nil {
+		c.schema = pf.Schema()
+	} else if pf.Schema().String() != c.schema.String() {
+		return errors.New("Schema of rows differs from the collected rows")
+	}
+	if c.w == nil {
+		f, err := os.CreateTemp(c.dir, ".parquetlogger-collect-*.parquet")
+		if err != nil {
+			return err
+		}
+		c.f = f
+		c.w = parquet.NewWriter(f,
+			c.schema,
+			parquet.Compression(parquet.LookupCompressionCodec(format.Snappy)),
+			parquet.MaxRowsPerRowGroup(c.rowGroupRows),
+		)
+	}
+	for _, rg := range pf.RowGroups() {
+		rows := rg.Rows()
+		_, err := parquet.CopyRows(c.w, rows)
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("Failed to write rows: %w", err)
+		}
+	}
+	return nil
+}
+
+var errNoRows = errors.New("No rows are collected")
+
+// exportPath returns the path of name in the rotate directory. name is
+// relative to the rotate directory, and a path outside it is refused, since
+// any client can request an export.
+func (c *collector) exportPath(name string) (string, error) {
+	dir, err := filepath.Abs(c.dir)
+	if err != nil {
+		return "", err
+	}
+	filename := name
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(dir, filename)
+	}
+	filename = filepath.Clean(filename)
+	if rel, err := filepath.Rel(dir, filename); err != nil || rel == "." || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%s is not in the rotate directory %s", name, dir)
+	}
+	return filename, nil
+}
+
+// export writes the collected rows sorted into filename and starts
+// collecting again. If it fails, the rows are kept for the next export.
+func (c *collector) export(filename string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.w != nil {
+		f, w := c.f, c.w
+		c.f, c.w = nil, nil
+		err := w.Close()
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+			return fmt.Errorf("Failed to close collected rows: %w", err)
+		}
+		c.pending = append(c.pending, f.Name())
+	}
+	if len(c.pending) == 0 {
+		return errNoRows
+	}
+	opts := compactOptions{RowGroupRows: c.rowGroupRows}
+	if _, ok := c.schema.Lookup(c.sortBy); ok {
+		opts.SortBy = c.sortBy
+	}
+	if err := compact(filename, c.pending, opts); err != nil {
+		return err
+	}
+	for _, name := range c.pending {
+		os.Remove(name)
+	}
+	c.pending, c.schema = nil, nil
+	return nil
+}
+
+// rotate exports the collected rows into the rotate directory.
+func (c *collector) rotate() (string, error) {
+	filename := filepath.Join(c.dir, "log-"+time.Now().Format("20060102-150405.000")+".parquet")
+	if err := c.export(filename); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func encodeRows[T any](t *testing.T, rows ...T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	addr := filepath.Join(dir, "collect.sock")
+	ln, err := net.Listen("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	c := &collector{dir: dir, sortBy: "StartTime", rowGroupRows: 100}
+	go c.serve(ln)
+
+	if _, err := command("unix", addr, frameRotate, nil); err == nil || err.Error() != errNoRows.Error() {
+		t.Errorf("got %v, want %v", err, errNoRows)
+	}
+
+	start := time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC)
+	at := func(sec int) testRow {
+		return testRow{StartTime: start.Add(time.Duration(sec) * time.Second), Pattern: "/"}
+	}
+	// Two clients send rows over their own connections.
+	for _, rows := range [][]testRow{{at(3), at(0)}, {at(4), at(1)}, {at(2)}} {
+		if _, err := command("unix", addr, frameRows, encodeRows(t, rows...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = command("unix", addr, frameRows, encodeRows(t, struct{ Other string }{"x"}))
+	if err == nil || !strings.Contains(err.Error(), "Schema") {
+		t.Errorf("got %v, want an error of the schema", err)
+	}
+
+	path, err := command("unix", addr, frameRotate, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(string(path)) != dir {
+		t.Errorf("got %s, want a file in %s", path, dir)
+	}
+	rows, err := parquet.ReadFile[testRow](string(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("got %d rows, want 5", len(rows))
+	}
+	for i, row := range rows {
+		if !row.StartTime.Equal(at(i).StartTime) {
+			t.Errorf("row %d: got %v, want %v", i, row.StartTime, at(i).StartTime)
+		}
+	}
+
+	// Rows after the export go to the next file.
+	if _, err := command("unix", addr, frameRows, encodeRows(t, at(5))); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "out.parquet")
+	if _, err := command("unix", addr, frameExport, []byte(dst)); err != nil {
+		t.Fatal(err)
+	}
+	if rows, err := parquet.ReadFile[testRow](dst); err != nil || len(rows) != 1 {
+		t.Errorf("got %d rows, %v, want 1 row", len(rows), err)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			t.Errorf("%s is left", e.Name())
+		}
+	}
+}
+
+func TestCollectExportPath(t *testing.T) {
+	dir := t.TempDir()
+	c := &collector{dir: dir}
+	for name, want := range map[string]string{
+		"out.parquet":                    filepath.Join(dir, "out.parquet"),
+		"dt=x/out.parquet":               filepath.Join(dir, "dt=x", "out.parquet"),
+		filepath.Join(dir, "a.parquet"):  filepath.Join(dir, "a.parquet"),
+		"../out.parquet":                 "",
+		"dt=x/../../out.parquet":         "",
+		".":                              "",
+		filepath.Join(os.TempDir(), "x"): "",
+	} {
+		got, err := c.exportPath(name)
+		if want == "" {
+			if err == nil {
+				t.Errorf("%s: got %s, want an error", name, got)
+			}
+		} else if got != want || err != nil {
+			t.Errorf("%s: got %s, %v, want %s", name, got, err, want)
+		}
+	}
+}
+
+func TestCollectExportFailureKeepsRows(t *testing.T) {
+	dir := t.TempDir()
+	c := &collector{dir: dir, sortBy: "StartTime", rowGroupRows: 100}
+	start := time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC)
+	if err := c.add(encodeRows(t, testRow{StartTime: start})); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.export(filepath.Join(dir, "missing", "out.parquet")); err == nil {
+		t.Fatal("got no error, want an error of the missing directory")
+	}
+	if err := c.add(encodeRows(t, struct{ Other string }{"x"})); err == nil {
+		t.Error("got no error, want an error of the schema")
+	}
+	if err := c.add(encodeRows(t, testRow{StartTime: start.Add(time.Second)})); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "out.parquet")
+	if err := c.export(dst); err != nil {
+		t.Fatal(err)
+	}
+	if rows, err := parquet.ReadFile[testRow](dst); err != nil || len(rows) != 2 {
+		t.Errorf("got %d rows, %v, want 2 rows", len(rows), err)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			t.Errorf("%s is left", e.Name())
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// compactOptions defines how files are compacted.
+type compactOptions struct {
+	// SortBy is the column to sort rows by, or empty to keep the order.
+	SortBy string
+	// RowGroupRows is the number of rows in a row group.
+	RowGroupRows int64
+	// Delete deletes the inputs after the output is verified.
+	Delete bool
+}
+
+func runCompact(args []string) error {
+	fs := flag.NewFlagSet("compact", flag.ExitOnError)
+	output := fs.String("o", "", "output `filename`")
+	opts := compactOptions{}
+	fs.StringVar(&opts.SortBy, "sort-by", "StartTime", "`column` to sort rows by, empty to keep the order")
+	fs.Int64Var(&opts.RowGroupRows, "row-group-rows", 1000000, "number of `rows` in a row group")
+	fs.BoolVar(&opts.Delete, "delete", false, "delete inputs after the output is verified")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s compact -o out.parquet [options] in.parquet...\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *output == "" || fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	return compact(*output, fs.Args(), opts)
+}
+
+type inputFile struct {
+	name string
+	f    *os.File
+	pf   *parquet.File
+}
+
+func openInput(name string) (*inputFile, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	pf, err := parquet.OpenFile(f, st.Size())
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Failed to open %s: %w", name, err)
+	}
+	return &inputFile{name: name, f: f, pf: pf}, nil
+}
+
+// compact merges srcs which have the same schema into dst.
+func compact(dst string, srcs []string, opts compactOptions) error {
+	inputs := make([]*inputFile, 0, len(srcs))
+	defer func() {
+		for _, in := range inputs {
+			in.f.Close()
+		}
+	}()
+	var numRows int64
+	for _, name := range srcs {
+		in, err := openInput(name)
+		if err != nil {
+			return err
+		}
+		if len(inputs) > 0 && in.pf.Schema().String() != inputs[0].pf.Schema().String() {
+			in.f.Close()
+			return fmt.Errorf("Schema of %s differs from %s", name, inputs[0].name)
+		}
+		inputs = append(inputs, in)
+		numRows += in.pf.NumRows()
+	}
+	schema := inputs[0].pf.Schema()
+
+	var sorting []parquet.SortingColumn
+	if opts.SortBy != "" {
+		if _, ok := schema.Lookup(opts.SortBy); !ok {
+			return fmt.Errorf("No column %s in %s", opts.SortBy, inputs[0].name)
+		}
+		sorting = []parquet.SortingColumn{parquet.Ascending(opts.SortBy)}
+	}
+
+	var rowGroups []parquet.RowGroup
+	for _, in := range inputs {
+		for _, rg := range in.pf.RowGroups() {
+			if len(sorting) > 0 && !isSortedBy(rg, sorting[0]) {
+				buf, err := sortRowGroup(rg, sorting)
+				if err != nil {
+					return fmt.Errorf("Failed to sort %s: %w", in.name, err)
+				}
+				rg = buf
+			}
+			rowGroups = append(rowGroups, rg)
+		}
+	}
+	merged, err := parquet.MergeRowGroups(rowGroups,
+		schema,
+		parquet.SortingRowGroupConfig(parquet.SortingColumns(sorting...)),
+	)
+	if err != nil {
+		return fmt.Errorf("Failed to merge row groups: %w", err)
+	}
+
+	tmp, err := createTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := parquet.NewWriter(tmp,
+		schema,
+		parquet.Compression(parquet.LookupCompressionCodec(format.Snappy)),
+		parquet.MaxRowsPerRowGroup(opts.RowGroupRows),
+		parquet.DataPageStatistics(true),
+		parquet.SortingWriterConfig(parquet.SortingColumns(sorting...)),
+	)
+	meta := mergeMetadata(inputs)
+	meta["row_count"] = strconv.FormatInt(numRows, 10)
+	meta["compacted_from"] = strconv.Itoa(len(inputs))
+	for k, v := range meta {
+		w.SetKeyValueMetadata(k, v)
+	}
+	rows := merged.Rows()
+	_, err = parquet.CopyRows(w, rows)
+	rows.Close()
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to write %s: %w", dst, err)
+	}
+	if err := w.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to close parquet writer: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := verify(tmp.Name(), schema, numRows); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return err
+	}
+	if err := syncDir(filepath.Dir(dst)); err != nil {
+		return err
+	}
+
+	if opts.Delete {
+		for _, in := range inputs {
+			if same, _ := sameFile(in.name, dst); same {
+				continue
+			}
+			if err := os.Remove(in.name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// createTemp creates a new file in dir like os.CreateTemp, but with mode
+// 0666 before umask like os.Create instead of 0600, since the file is
+// renamed to its final path.
+func createTemp(dir, prefix string) (*os.File, error) {
+	for range 10000 {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return f, err
+	}
+	return nil, &fs.PathError{Op: "createtemp", Path: filepath.Join(dir, prefix+"*"), Err: fs.ErrExist}
+}
+
+func isSortedBy(rg parquet.RowGroup, col parquet.SortingColumn) bool {
+	sorting := rg.SortingColumns()
+	return len(sorting) > 0 &&
+		slices.Equal(sorting[0].Path(), col.Path()) &&
+		sorting[0].Descending() == col.Descending()
+}
+
+// sortRowGroup loads rg into memory and sorts it.
+func sortRowGroup(rg parquet.RowGroup, sorting []parquet.SortingColumn) (*parquet.Buffer, error) {
+	buf := parquet.NewBuffer(rg.Schema(), parquet.SortingRowGroupConfig(parquet.SortingColumns(sorting...)))
+	rows := rg.Rows()
+	defer rows.Close()
+	if _, err := parquet.CopyRows(buf, rows); err != nil {
+		return nil, err
+	}
+	sort.Stable(buf)
+	return buf, nil
+}
+
+// verify checks that filename is readable and holds numRows rows of schema.
+func verify(filename string, schema *parquet.Schema, numRows int64) error {
+	out, err := openInput(filename)
+	if err != nil {
+		return err
+	}
+	defer out.f.Close()
+	if out.pf.Schema().String() != schema.String() {
+		return errors.New("Schema of the output differs from the inputs")
+	}
+	if n := out.pf.NumRows(); n != numRows {
+		return fmt.Errorf("Output has %d rows, want %d", n, numRows)
+	}
+	return nil
+}
+
+// mergeMetadata merges key/value metadata of inputs. Times and counts
+// written by the Logger are aggregated, the same values are kept and
+// different values are joined with commas.
+func mergeMetadata(inputs []*inputFile) map[string]string {
+	values := make(map[string][]string)
+	for _, in := range inputs {
+		for _, kv := range in.pf.Metadata().KeyValueMetadata {
+			values[kv.Key] = append(values[kv.Key], kv.Value)
+		}
+	}
+	meta := make(map[string]string, len(values))
+	for k, vs := range values {
+		switch k {
+		case "first_start_time", "last_start_time":
+			var ts []time.Time
+			for _, v := range vs {
+				if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+					ts = append(ts, t)
+				}
+			}
+			if len(ts) == 0 {
+				continue
+			}
+			t := slices.MinFunc(ts, time.Time.Compare)
+			if k == "last_start_time" {
+				t = slices.MaxFunc(ts, time.Time.Compare)
+			}
+			meta[k] = t.Format(time.RFC3339Nano)
+		case "row_count", "dropped_count":
+			var sum int64
+			for _, v := range vs {
+				n, _ := strconv.ParseInt(v, 10, 64)
+				sum += n
+			}
+			meta[k] = strconv.FormatInt(sum, 10)
+		default:
+			slices.Sort(vs)
+			meta[k] = strings.Join(slices.Compact(vs), ",")
+		}
+	}
+	return meta
+}
+
+func sameFile(a, b string) (bool, error) {
+	sa, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	sb, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(sa, sb), nil
+}
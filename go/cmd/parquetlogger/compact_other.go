@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+// syncDir does nothing, since a directory cannot be synced on Windows: it
+// is opened read-only, and FlushFileBuffers needs a writable handle.
+func syncDir(dir string) error {
+	return nil
+}
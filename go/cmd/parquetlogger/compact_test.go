@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+type testRow struct {
+	StartTime time.Time
+	Pattern   string
+}
+
+func writeTestFile(t *testing.T, filename string, meta map[string]string, rows ...testRow) {
+	t.Helper()
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := parquet.NewGenericWriter[testRow](f)
+	for k, v := range meta {
+		w.SetKeyValueMetadata(k, v)
+	}
+	if _, err := w.Write(rows); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC)
+	at := func(sec int) testRow {
+		return testRow{StartTime: start.Add(time.Duration(sec) * time.Second), Pattern: "/"}
+	}
+	srcs := []string{
+		filepath.Join(dir, "log-1.parquet"),
+		filepath.Join(dir, "log-2.parquet"),
+		filepath.Join(dir, "log-3.parquet"),
+	}
+	writeTestFile(t, srcs[0], map[string]string{"hostname": "a", "dropped_count": "1", "first_start_time": start.Add(3 * time.Second).Format(time.RFC3339Nano)}, at(4), at(3))
+	writeTestFile(t, srcs[1], map[string]string{"hostname": "b", "dropped_count": "2", "first_start_time": start.Format(time.RFC3339Nano)}, at(0), at(2))
+	writeTestFile(t, srcs[2], map[string]string{"hostname": "a"}, at(1))
+
+	dst := filepath.Join(dir, "compacted.parquet")
+	if err := compact(dst, srcs, compactOptions{SortBy: "StartTime", RowGroupRows: 2, Delete: true}); err != nil {
+		t.Fatalf("Failed to compact: %v", err)
+	}
+	for _, src := range srcs {
+		if _, err := os.Stat(src); !os.IsNotExist(err) {
+			t.Errorf("%s is not deleted: %v", src, err)
+		}
+	}
+
+	out, err := openInput(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.f.Close()
+	if n := len(out.pf.RowGroups()); n != 3 {
+		t.Errorf("got %d row groups, want 3", n)
+	}
+	rows, err := parquet.Read[testRow](out.f, mustSize(t, dst))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range rows {
+		if !row.StartTime.Equal(at(i).StartTime) {
+			t.Errorf("row %d: got %v, want %v", i, row.StartTime, at(i).StartTime)
+		}
+	}
+	for k, want := range map[string]string{
+		"hostname":         "a,b",
+		"dropped_count":    "3",
+		"row_count":        "5",
+		"first_start_time": start.Format(time.RFC3339Nano),
+		"compacted_from":   "3",
+	} {
+		if got, _ := out.pf.Lookup(k); got != want {
+			t.Errorf("%s: got %q, want %q", k, got, want)
+		}
+	}
+}
+
+func TestCompactSchemaMismatch(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.parquet")
+	b := filepath.Join(dir, "b.parquet")
+	writeTestFile(t, a, nil, testRow{})
+	if err := parquet.WriteFile(b, []struct{ Other string }{{"x"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := compact(filepath.Join(dir, "out.parquet"), []string{a, b}, compactOptions{}); err == nil {
+		t.Fatal("Compact of different schemas succeeded")
+	}
+	if _, err := os.Stat(b); err != nil {
+		t.Errorf("input is deleted: %v", err)
+	}
+}
+
+func mustSize(t *testing.T, filename string) int64 {
+	t.Helper()
+	st, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st.Size()
+}
@@ -0,0 +1,34 @@
+// Command parquetlogger is a toolbox for parquet files written by the middlewares.
+//
+//	parquetlogger compact -o out.parquet [-row-group-rows N] [-delete] in.parquet...
+//	parquetlogger collect [-network unix] [-addr path] [-rotate-dir dir] [export out.parquet | rotate]
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [arguments]\n\nCommands:\n  compact  merge parquet files into one sorted file\n  collect  collect rows of several processes into one file\n", os.Args[0])
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "compact":
+		err = runCompact(os.Args[2:])
+	case "collect":
+		err = runCollect(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,189 @@
+package echo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryConfig defines how requests to a remote destination are retried.
+type RetryConfig struct {
+	// Client sends requests. The default is a client with a timeout of 10
+	// seconds.
+	Client *http.Client
+	// MaxRetries is the number of retries of a failed request. The default
+	// is 5.
+	MaxRetries int
+	// RetryInterval is the first interval between retries, which doubles on
+	// every retry up to 30 seconds. The default is a second.
+	RetryInterval time.Duration
+	// OnError is called when a request in background finally fails. The
+	// default logs the error by slog.Default().
+	OnError func(error)
+}
+
+func (cfg *RetryConfig) setDefaults() {
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = time.Second
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(err error) {
+			slog.Error(err.Error())
+		}
+	}
+}
+
+// maxRetryInterval bounds the interval between retries.
+const maxRetryInterval = 30 * time.Second
+
+// retry calls fn until it succeeds or fails maxRetries times more. fn
+// returns the interval before a retry, which is 0 to use the backoff or
+// negative if the error is permanent.
+func (cfg *RetryConfig) retry(fn func() (time.Duration, error)) error {
+	interval := cfg.RetryInterval
+	for attempt := 0; ; attempt++ {
+		wait, err := fn()
+		if err == nil {
+			return nil
+		}
+		if wait < 0 || attempt >= cfg.MaxRetries {
+			return err
+		}
+		if wait == 0 {
+			wait = interval
+		}
+		time.Sleep(wait)
+		interval = min(interval*2, maxRetryInterval)
+	}
+}
+
+// send sends requests made by newReq until one succeeds, and passes the
+// successful response to handle if it is not nil. 429 and server errors
+// other than 501 are retried.
+func (cfg *RetryConfig) send(newReq func() (*http.Request, error), handle func(*http.Response) error) error {
+	return cfg.retry(func() (time.Duration, error) {
+		req, err := newReq()
+		if err != nil {
+			return -1, err
+		}
+		resp, err := cfg.Client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			if handle != nil {
+				if err := handle(resp); err != nil {
+					return -1, err
+				}
+			}
+			io.Copy(io.Discard, resp.Body)
+			return 0, nil
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err = fmt.Errorf("Unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests,
+			resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented:
+			wait := time.Duration(0)
+			if sec, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && sec >= 0 {
+				wait = min(time.Duration(sec)*time.Second, maxRetryInterval)
+			}
+			return wait, err
+		default:
+			return -1, err
+		}
+	})
+}
+
+// BatchConfig defines how a sink sends rows to a remote destination.
+type BatchConfig struct {
+	// MaxBatchRows is the maximum number of rows in a request.
+	MaxBatchRows int
+	// MaxQueuedBatches is the number of requests waiting to be sent. Rows
+	// are dropped with an error when the queue is full. The default is 8.
+	MaxQueuedBatches int
+	RetryConfig
+}
+
+func (cfg *BatchConfig) setDefaults(maxBatchRows int) {
+	if cfg.MaxBatchRows <= 0 {
+		cfg.MaxBatchRows = maxBatchRows
+	}
+	if cfg.MaxQueuedBatches <= 0 {
+		cfg.MaxQueuedBatches = 8
+	}
+	cfg.RetryConfig.setDefaults()
+}
+
+// batchQueue sends batches of entries in background, so that a slow
+// destination does not block the Logger.
+type batchQueue[E any] struct {
+	cfg     *BatchConfig
+	send    func(batch []E) error
+	pending []E
+	queue   chan []E
+	done    chan struct{}
+}
+
+func newBatchQueue[E any](cfg *BatchConfig, send func(batch []E) error) *batchQueue[E] {
+	q := &batchQueue[E]{
+		cfg:   cfg,
+		send:  send,
+		queue: make(chan []E, cfg.MaxQueuedBatches),
+		done:  make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// add appends e to the pending batch and queues the batch if it is full.
+func (q *batchQueue[E]) add(e E) error {
+	q.pending = append(q.pending, e)
+	if len(q.pending) >= q.cfg.MaxBatchRows {
+		return q.flush()
+	}
+	return nil
+}
+
+// flush queues the pending batch.
+func (q *batchQueue[E]) flush() error {
+	if len(q.pending) == 0 {
+		return nil
+	}
+	batch := q.pending
+	q.pending = nil
+	select {
+	case q.queue <- batch:
+		return nil
+	default:
+		return fmt.Errorf("Failed to queue %d rows: Queue is full", len(batch))
+	}
+}
+
+// close queues the pending batch and waits for queued batches to be sent.
+func (q *batchQueue[E]) close() error {
+	err := q.flush()
+	close(q.queue)
+	<-q.done
+	return err
+}
+
+func (q *batchQueue[E]) run() {
+	defer close(q.done)
+	for batch := range q.queue {
+		if err := q.send(batch); err != nil {
+			q.cfg.OnError(err)
+		}
+	}
+}
@@ -4,128 +4,41 @@ package echo
 import (
 	"errors"
 	"fmt"
-	"io"
-	"log"
-	"os"
 	"time"
 
 	"github.com/labstack/echo/v4"
-	"github.com/parquet-go/parquet-go"
-	"github.com/parquet-go/parquet-go/format"
 )
 
-// RowType contains extracted values from logger.
-type RowType struct {
-	StartTime       time.Time           `parquet:",delta"`
-	Latency         time.Duration       `parquet:",delta"`
-	Protocol        string              `parquet:",dict"`
-	RemoteAddr      string              `parquet:",dict"`
-	Host            string              `parquet:",dict"`
-	Method          string              `parquet:",dict"`
-	URL             string              `parquet:",dict"`
-	Pattern         string              `parquet:",dict"`
-	Status          int                 `parquet:",dict"`
-	RequestSize     int64               `parquet:",delta"`
-	ResponseSize    int64               `parquet:",delta"`
-	RequestHeaders  map[string][]string `parquet:","`
-	ResponseHeaders map[string][]string `parquet:","`
-	Error           *string             `parquet:","`
-}
-
-// A Logger defines parameters for logging.
-type Logger struct {
-	ch       chan RowType
-	exportCh chan string
-	doneCh   chan error
-}
-
-// NewLogger returns a new Logger.
-func NewLogger() *Logger {
-	pl := &Logger{
-		ch:     make(chan RowType, 64),
-		doneCh: make(chan error),
-	}
-	go func() {
-		pl.doneCh <- pl.run()
-	}()
-	return pl
-}
-
-func (pl *Logger) run() error {
-	if pl.ch == nil {
-		log.Fatal("No channel is defined in Logger. Please use NewLogger")
-	}
-	f, err := os.CreateTemp("", ".parquet-logger-*.parquet")
-	if err != nil {
-		log.Fatalf("Failed to create tempfile: %v", err)
-	}
-	os.Remove(f.Name())
-	w := parquet.NewGenericWriter[RowType](f, parquet.Compression(parquet.LookupCompressionCodec(format.Snappy)))
+// adapterName is recorded in the metadata of exported files.
+const adapterName = "echo"
 
-	pl.exportCh = make(chan string)
-	var filename string
-
-L:
-	for {
-		select {
-		case row, ok := <-pl.ch:
-			if !ok {
-				break L
-			}
-			if _, err := w.Write([]RowType{row}); err != nil {
-				log.Printf("Failed to write parquet: %v", err)
-			}
-		case filename = <-pl.exportCh:
-			break L
-		}
-	}
-	if err := w.Close(); err != nil {
-		return fmt.Errorf("Failed to close parquet writer: %w", err)
-	}
-	if filename != "" {
-		out, err := os.Create(filename)
-		if err != nil {
-			return fmt.Errorf("Failed to create %s: %w", filename, err)
-		}
-		if err := f.Sync(); err != nil {
-			return fmt.Errorf("Failed to sync tempfile: %w", err)
-		}
-		if _, err := f.Seek(0, io.SeekStart); err != nil {
-			return fmt.Errorf("Failed to seek tempfile: %w", err)
-		}
-		if _, err := io.Copy(out, f); err != nil {
-			return fmt.Errorf("Failed to copy from %s to %s: %v", f.Name(), out.Name(), err)
-		}
-		if err := out.Close(); err != nil {
-			return fmt.Errorf("Failed to close %s: %w", out.Name(), err)
-		}
-		log.Printf("Succeed to export %s", filename)
-	}
-	if err := f.Close(); err != nil {
-		return fmt.Errorf("Failed to close tempfile: %w", err)
-	}
-	return nil
-}
-
-// Export exports parquet file.
-func (pl *Logger) Export(filename string) error {
-	pl.exportCh <- filename
-	go func() {
-		pl.doneCh <- pl.run()
-	}()
-	return <-pl.doneCh
-}
-
-func (pl *Logger) send(row RowType) {
-	select {
-	case pl.ch <- row:
-	default:
-		log.Printf("Failed to add to channel: Capacity limit reached. Consider increasing the channel size.")
-	}
+// RequestInfo contains values of a request which are passed to an extractor.
+type RequestInfo struct {
+	StartTime time.Time
+	Latency   time.Duration
+	Protocol  string
+	// Scheme is http or https.
+	Scheme          string
+	RemoteAddr      string
+	Host            string
+	Method          string
+	URL             string
+	Pattern         string
+	Status          int
+	RequestSize     int64
+	ResponseSize    int64
+	RequestHeaders  map[string][]string
+	ResponseHeaders map[string][]string
+	Error           *string
+	Labels          Labels
+	// Runtime is set by WithRuntimeStats.
+	Runtime *RuntimeStats
+	// Context is the context of the served request.
+	Context echo.Context
 }
 
 // Middleware returns logger middleware.
-func (pl *Logger) Middleware() echo.MiddlewareFunc {
+func (pl *GenericLogger[T]) Middleware() echo.MiddlewareFunc {
 	now := time.Now
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -133,17 +46,20 @@ func (pl *Logger) Middleware() echo.MiddlewareFunc {
 			req := c.Request()
 			res := c.Response()
 			start := now()
+			rs := pl.runtimeStart()
 
 			// Next
 			err := next(c)
 
 			//After
 			latency := now().Sub(start)
+			pl.runtimeEnd(rs)
 
-			row := RowType{
+			info := RequestInfo{
 				StartTime:       start,
 				Latency:         latency,
 				Protocol:        req.Proto,
+				Scheme:          c.Scheme(),
 				RemoteAddr:      c.RealIP(),
 				Host:            req.Host,
 				Method:          req.Method,
@@ -154,19 +70,21 @@ func (pl *Logger) Middleware() echo.MiddlewareFunc {
 				ResponseSize:    res.Size,
 				RequestHeaders:  req.Header,
 				ResponseHeaders: res.Header(),
+				Runtime:         rs,
+				Context:         c,
 			}
 			if err != nil {
 				var httpErr *echo.HTTPError
 				if errors.As(err, &httpErr) {
-					row.Status = httpErr.Code
+					info.Status = httpErr.Code
 					errStr := fmt.Sprintf("%v", httpErr.Message)
-					row.Error = &errStr
+					info.Error = &errStr
 				} else {
 					errStr := err.Error()
-					row.Error = &errStr
+					info.Error = &errStr
 				}
 			}
-			pl.send(row)
+			pl.log(info)
 			return err
 		}
 	}
@@ -0,0 +1,225 @@
+package echo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// forwardStub is a Fluent Forward input which drops the first connections
+// without acknowledging messages.
+type forwardStub struct {
+	ln    net.Listener
+	drops int
+
+	mu      sync.Mutex
+	tags    []string
+	entries [][]any
+	wg      sync.WaitGroup
+}
+
+func newForwardStub(t *testing.T, network, address string, drops int) *forwardStub {
+	t.Helper()
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &forwardStub{ln: ln, drops: drops}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+func (s *forwardStub) close() {
+	s.ln.Close()
+	s.wg.Wait()
+}
+
+func (s *forwardStub) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			for {
+				v, err := readMsgpack(r)
+				if err != nil {
+					return
+				}
+				s.mu.Lock()
+				drop := s.drops > 0
+				if drop {
+					s.drops--
+				}
+				s.mu.Unlock()
+				if drop {
+					return
+				}
+				msg := v.([]any)
+				s.mu.Lock()
+				s.tags = append(s.tags, msg[0].(string))
+				for _, e := range msg[1].([]any) {
+					s.entries = append(s.entries, e.([]any))
+				}
+				s.mu.Unlock()
+				if chunk, ok := msg[2].(map[string]any)["chunk"].(string); ok {
+					conn.Write(appendMsgpackString(appendMsgpackMapHeader(nil, 1), "ack"))
+					conn.Write(appendMsgpackString(nil, chunk))
+				}
+			}
+		}()
+	}
+}
+
+func TestFluentSink(t *testing.T) {
+	stub := newForwardStub(t, "tcp", "127.0.0.1:0", 0)
+	s := NewFluentSink(FluentConfig{
+		Address:    stub.ln.Addr().String(),
+		Tag:        "access.app",
+		RequireAck: true,
+		BatchConfig: BatchConfig{
+			MaxBatchRows: 2,
+			RetryConfig: RetryConfig{
+				OnError: func(err error) {
+					t.Errorf("Failed to forward: %v", err)
+				},
+			},
+		},
+	})
+	msg := "boom"
+	start := time.Date(2026, 10, 18, 12, 0, 0, 123456789, time.UTC)
+	rows := []RowType{
+		{StartTime: start, Latency: 1500 * time.Millisecond, Method: "GET", URL: "/user/1", Status: 200, RequestHeaders: map[string][]string{"Accept": {"*/*"}}, Service: "api"},
+		{StartTime: start, Method: "POST", URL: "/user", Status: 500, Error: &msg},
+		{StartTime: start, Method: "GET", URL: "/", Status: 404},
+	}
+	if err := s.Write(rows); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	stub.close()
+
+	if len(stub.tags) != 2 || stub.tags[0] != "access.app" {
+		t.Fatalf("got tags %v, want 2 messages of access.app", stub.tags)
+	}
+	if len(stub.entries) != len(rows) {
+		t.Fatalf("got %d entries, want %d", len(stub.entries), len(rows))
+	}
+	ext, ok := stub.entries[0][0].(msgpackExt)
+	if !ok || ext.Type != 0 || len(ext.Data) != 8 {
+		t.Fatalf("got time %#v, want EventTime", stub.entries[0][0])
+	}
+	sec, nsec := binary.BigEndian.Uint32(ext.Data), binary.BigEndian.Uint32(ext.Data[4:])
+	if got := time.Unix(int64(sec), int64(nsec)); !got.Equal(start) {
+		t.Errorf("got time %v, want %v", got, start)
+	}
+	rec := stub.entries[0][1].(map[string]any)
+	if rec["Method"] != "GET" || rec["Status"] != int64(200) || rec["Latency"] != int64(1500*time.Millisecond) || rec["Service"] != "api" {
+		t.Errorf("got record %v", rec)
+	}
+	if h := rec["RequestHeaders"].(map[string]any)["Accept"].([]any); len(h) != 1 || h[0] != "*/*" {
+		t.Errorf("got RequestHeaders %v", rec["RequestHeaders"])
+	}
+	if rec["Error"] != nil {
+		t.Errorf("got Error %v, want nil", rec["Error"])
+	}
+	if _, ok := rec["Version"]; ok {
+		t.Errorf("got empty label Version in %v", rec)
+	}
+	if rec := stub.entries[1][1].(map[string]any); rec["Error"] != msg {
+		t.Errorf("got Error %v, want %s", rec["Error"], msg)
+	}
+}
+
+func TestFluentSinkReconnect(t *testing.T) {
+	stub := newForwardStub(t, "unix", filepath.Join(t.TempDir(), "fluent.sock"), 2)
+	s := NewFluentSink(FluentConfig{
+		Network:    "unix",
+		Address:    stub.ln.Addr().String(),
+		RequireAck: true,
+		BatchConfig: BatchConfig{
+			RetryConfig: RetryConfig{
+				RetryInterval: time.Millisecond,
+				OnError: func(err error) {
+					t.Errorf("Failed to forward: %v", err)
+				},
+			},
+		},
+	})
+	if err := s.Write([]RowType{{StartTime: time.Now(), Status: 200}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	stub.close()
+
+	if len(stub.tags) != 1 || stub.tags[0] != "parquetlogger" {
+		t.Fatalf("got tags %v, want a message of parquetlogger", stub.tags)
+	}
+	if len(stub.entries) != 1 {
+		t.Errorf("got %d entries, want 1", len(stub.entries))
+	}
+}
+
+func TestMsgpack(t *testing.T) {
+	b := appendMsgpackArrayHeader(nil, 20)
+	for _, n := range []int64{0, 127, 128, -1, -32, -33, 1 << 40, -1 << 40} {
+		b = appendMsgpackInt(b, n)
+	}
+	for _, n := range []int{0, 31, 32, 255, 256, 1 << 16} {
+		b = appendMsgpackString(b, string(make([]byte, n)))
+	}
+	b = appendMsgpackMapHeader(b, 16)
+	for i := 0; i < 16; i++ {
+		b = appendMsgpackString(b, string(rune('a'+i)))
+		b = appendMsgpackNil(b)
+	}
+	b = appendMsgpackArrayHeader(b, 1<<16)
+	for i := 0; i < 1<<16; i++ {
+		b = appendMsgpackInt(b, 1)
+	}
+	b = appendMsgpackArrayHeader(b, 0)
+	b = appendMsgpackEventTime(b, time.Unix(1, 2))
+	b = appendMsgpackNil(b)
+	b = appendMsgpackNil(b)
+	b = appendMsgpackNil(b)
+
+	v, err := readMsgpack(bufio.NewReader(bytes.NewReader(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := v.([]any)
+	for i, n := range []int64{0, 127, 128, -1, -32, -33, 1 << 40, -1 << 40} {
+		if a[i] != n {
+			t.Errorf("got %v, want %d", a[i], n)
+		}
+	}
+	for i, n := range []int{0, 31, 32, 255, 256, 1 << 16} {
+		if s := a[8+i].(string); len(s) != n {
+			t.Errorf("got a string of %d bytes, want %d", len(s), n)
+		}
+	}
+	if m := a[14].(map[string]any); len(m) != 16 {
+		t.Errorf("got a map of %d entries, want 16", len(m))
+	}
+	if l := a[15].([]any); len(l) != 1<<16 {
+		t.Errorf("got an array of %d items, want %d", len(l), 1<<16)
+	}
+	if ext := a[17].(msgpackExt); ext.Type != 0 || string(ext.Data) != "\x00\x00\x00\x01\x00\x00\x00\x02" {
+		t.Errorf("got %#v, want EventTime of 1.000000002", ext)
+	}
+}
@@ -0,0 +1,228 @@
+package echo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MetricsConfig defines metrics maintained by Metrics.
+type MetricsConfig struct {
+	// Namespace is prepended to metric names with an underscore, such as
+	// myapp_http_requests_total. The default is none.
+	Namespace string
+	// Buckets are upper bounds of the duration histogram in seconds. The
+	// default is the same as the Prometheus client, from 5ms to 10s.
+	Buckets []float64
+}
+
+// Metrics maintains Prometheus counters and histograms of rows labeled by
+// Method, Pattern and the class of Status such as 2xx. Methods other than
+// the standard ones are labeled _OTHER. It is a Sink which is updated by the
+// Logger, and an http.Handler which serves the metrics in the text
+// exposition format. It also counts rows dropped by the Logger which it is
+// added to.
+type Metrics struct {
+	cfg     MetricsConfig
+	mu      sync.Mutex
+	series  map[metricsKey]*metricsSeries
+	dropped func() int64
+}
+
+var (
+	_ Sink[RowType] = (*Metrics)(nil)
+	_ http.Handler  = (*Metrics)(nil)
+	_ droppedSetter = (*Metrics)(nil)
+)
+
+type metricsKey struct {
+	method, pattern, statusClass string
+}
+
+type metricsSeries struct {
+	count        uint64
+	durationSum  float64
+	buckets      []uint64
+	requestSize  int64
+	responseSize int64
+}
+
+// NewMetrics returns Metrics. Pass it to AddSink to update it, and
+// serve it at /metrics.
+func NewMetrics(cfg MetricsConfig) *Metrics {
+	if len(cfg.Buckets) == 0 {
+		cfg.Buckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	}
+	cfg.Buckets = slices.Clone(cfg.Buckets)
+	slices.Sort(cfg.Buckets)
+	return &Metrics{cfg: cfg, series: make(map[metricsKey]*metricsSeries)}
+}
+
+// Write implements Sink.
+func (m *Metrics) Write(rows []RowType) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range rows {
+		row := &rows[i]
+		key := metricsKey{methodLabel(row.Method), row.Pattern, statusClass(row.Status)}
+		s := m.series[key]
+		if s == nil {
+			s = &metricsSeries{buckets: make([]uint64, len(m.cfg.Buckets))}
+			m.series[key] = s
+		}
+		d := row.Latency.Seconds()
+		s.count++
+		s.durationSum += d
+		if i, _ := slices.BinarySearch(m.cfg.Buckets, d); i < len(s.buckets) {
+			s.buckets[i]++
+		}
+		// A negative size is unknown, e.g. a streamed body.
+		s.requestSize += max(row.RequestSize, 0)
+		s.responseSize += max(row.ResponseSize, 0)
+	}
+	return nil
+}
+
+func (m *Metrics) setDropped(dropped func() int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dropped = dropped
+}
+
+// Flush implements Sink.
+func (m *Metrics) Flush() error {
+	return nil
+}
+
+// Close implements Sink. Metrics are still served after Close.
+func (m *Metrics) Close() error {
+	return nil
+}
+
+// methodLabel bounds the cardinality of the method label, since the method
+// is sent by the client.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodDelete,
+		http.MethodConnect, http.MethodOptions, http.MethodTrace, http.MethodPatch:
+		return method
+	}
+	return "_OTHER"
+}
+
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// ServeHTTP implements http.Handler.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	keys := make([]metricsKey, 0, len(m.series))
+	series := make([]metricsSeries, 0, len(m.series))
+	for k := range m.series {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b metricsKey) int {
+		return strings.Compare(a.method+"\x00"+a.pattern+"\x00"+a.statusClass, b.method+"\x00"+b.pattern+"\x00"+b.statusClass)
+	})
+	for _, k := range keys {
+		s := *m.series[k]
+		s.buckets = slices.Clone(s.buckets)
+		series = append(series, s)
+	}
+	dropped := m.dropped
+	m.mu.Unlock()
+
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	name := func(s string) string {
+		if m.cfg.Namespace == "" {
+			return s
+		}
+		return m.cfg.Namespace + "_" + s
+	}
+	header := func(metric, typ, help string) {
+		cw.printf("# HELP %s %s\n# TYPE %s %s\n", metric, help, metric, typ)
+	}
+
+	requests := name("http_requests_total")
+	header(requests, "counter", "Number of HTTP requests.")
+	for i, k := range keys {
+		cw.printf("%s{%s} %d\n", requests, k.labels(), series[i].count)
+	}
+	duration := name("http_request_duration_seconds")
+	header(duration, "histogram", "Latency of HTTP requests in seconds.")
+	for i, k := range keys {
+		labels := k.labels()
+		var cum uint64
+		for j, le := range m.cfg.Buckets {
+			cum += series[i].buckets[j]
+			cw.printf("%s_bucket{%s,le=\"%s\"} %d\n", duration, labels, formatFloat(le), cum)
+		}
+		cw.printf("%s_bucket{%s,le=\"+Inf\"} %d\n", duration, labels, series[i].count)
+		cw.printf("%s_sum{%s} %s\n", duration, labels, formatFloat(series[i].durationSum))
+		cw.printf("%s_count{%s} %d\n", duration, labels, series[i].count)
+	}
+	requestSize := name("http_request_size_bytes_total")
+	header(requestSize, "counter", "Total size of HTTP request bodies in bytes.")
+	for i, k := range keys {
+		cw.printf("%s{%s} %d\n", requestSize, k.labels(), series[i].requestSize)
+	}
+	responseSize := name("http_response_size_bytes_total")
+	header(responseSize, "counter", "Total size of HTTP response bodies in bytes.")
+	for i, k := range keys {
+		cw.printf("%s{%s} %d\n", responseSize, k.labels(), series[i].responseSize)
+	}
+	if dropped != nil {
+		droppedRows := name("dropped_rows_total")
+		header(droppedRows, "counter", "Number of rows dropped by the Logger.")
+		cw.printf("%s %d\n", droppedRows, dropped())
+	}
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+func (k metricsKey) labels() string {
+	return `method="` + escapeLabel(k.method) + `",pattern="` + escapeLabel(k.pattern) + `",status_class="` + k.statusClass + `"`
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// countWriter counts written bytes and keeps the first error.
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countWriter) printf(format string, args ...any) {
+	if cw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(cw.w, format, args...)
+	cw.n += int64(n)
+	cw.err = err
+}
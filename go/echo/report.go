@@ -0,0 +1,89 @@
+package echo
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotInitialized is returned when a Logger is not created by NewLogger.
+	ErrNotInitialized = errors.New("No channel is defined in Logger. Please use NewLogger")
+	// ErrChannelFull is reported when a row is dropped because the channel is full.
+	ErrChannelFull = errors.New("Failed to add to channel: Capacity limit reached. Consider increasing the channel size")
+)
+
+// reportInterval is the minimum interval between log messages with the same text.
+const reportInterval = 10 * time.Second
+
+// maxReportKeys bounds the number of distinct messages remembered.
+const maxReportKeys = 1024
+
+// rateLimiter suppresses repeated messages.
+type rateLimiter struct {
+	mu         sync.Mutex
+	last       map[string]time.Time
+	suppressed map[string]int
+}
+
+// allow reports whether msg may be logged at now, and how many messages
+// were suppressed since it was logged last time.
+func (rl *rateLimiter) allow(msg string, now time.Time) (bool, int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.last == nil {
+		rl.last = make(map[string]time.Time)
+		rl.suppressed = make(map[string]int)
+	}
+	if _, ok := rl.last[msg]; !ok && len(rl.last) >= maxReportKeys {
+		rl.evict(now)
+	}
+	if last, ok := rl.last[msg]; ok && now.Sub(last) < reportInterval {
+		rl.suppressed[msg]++
+		return false, 0
+	}
+	n := rl.suppressed[msg]
+	rl.last[msg] = now
+	delete(rl.suppressed, msg)
+	return true, n
+}
+
+// evict forgets messages logged more than reportInterval ago, or the
+// oldest message if there is none.
+func (rl *rateLimiter) evict(now time.Time) {
+	var oldest string
+	var oldestTime time.Time
+	for msg, last := range rl.last {
+		if now.Sub(last) >= reportInterval {
+			delete(rl.last, msg)
+			delete(rl.suppressed, msg)
+		} else if oldestTime.IsZero() || last.Before(oldestTime) {
+			oldest, oldestTime = msg, last
+		}
+	}
+	if len(rl.last) >= maxReportKeys {
+		delete(rl.last, oldest)
+		delete(rl.suppressed, oldest)
+	}
+}
+
+func (pl *GenericLogger[T]) reportError(err error) {
+	msg := err.Error()
+	ok, suppressed := pl.limiter.allow(msg, time.Now())
+	if !ok {
+		return
+	}
+	if pl.cfg.onError != nil {
+		pl.cfg.onError(err)
+	}
+	logger := pl.cfg.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if suppressed > 0 {
+		logger.Error(msg, "suppressed", suppressed)
+	} else {
+		logger.Error(msg)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package echo
+
+import (
+	"os"
+	"syscall"
+)
+
+// Default signals of SignalConfig.
+var (
+	defaultExportSignal os.Signal = syscall.SIGUSR1
+	defaultRotateSignal os.Signal = syscall.SIGHUP
+	defaultFlushSignal  os.Signal = syscall.SIGTERM
+)
+
+// raiseSignal raises the flush signal again.
+var raiseSignal = reraise
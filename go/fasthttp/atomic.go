@@ -0,0 +1,89 @@
+package fasthttp
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// atomicFile is written into a sibling tempfile and renamed to its final
+// path on Close, so that readers never see a partial file.
+type atomicFile struct {
+	*os.File
+	path      string
+	overwrite bool
+	// onCommit is called with path after the file is moved to it.
+	onCommit func(path string)
+}
+
+func createAtomic(path string, overwrite bool) (*atomicFile, error) {
+	if !overwrite {
+		if _, err := os.Lstat(path); err == nil {
+			return nil, &fs.PathError{Op: "create", Path: path, Err: fs.ErrExist}
+		}
+	}
+	dir, base := filepath.Split(path)
+	f, err := createTemp(dir, "."+base+".tmp-")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{File: f, path: path, overwrite: overwrite}, nil
+}
+
+// createTemp creates a new file in dir like os.CreateTemp, but with mode
+// 0666 before umask like os.Create instead of 0600, since the file is
+// renamed to its final path.
+func createTemp(dir, prefix string) (*os.File, error) {
+	for range 10000 {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return f, err
+	}
+	return nil, &fs.PathError{Op: "createtemp", Path: filepath.Join(dir, prefix+"*"), Err: fs.ErrExist}
+}
+
+// Close syncs the tempfile and moves it to the final path.
+func (af *atomicFile) Close() error {
+	tmp := af.File.Name()
+	if err := af.File.Sync(); err != nil {
+		af.Abort()
+		return fmt.Errorf("Failed to sync %s: %w", tmp, err)
+	}
+	if err := af.File.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("Failed to close %s: %w", tmp, err)
+	}
+	if af.overwrite {
+		if err := os.Rename(tmp, af.path); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	} else {
+		// Link fails if the destination exists.
+		err := os.Link(tmp, af.path)
+		os.Remove(tmp)
+		if err != nil {
+			return err
+		}
+	}
+	if err := syncDir(filepath.Dir(af.path)); err != nil {
+		return err
+	}
+	if af.onCommit != nil {
+		af.onCommit(af.path)
+	}
+	return nil
+}
+
+// Abort removes the tempfile without touching the final path.
+func (af *atomicFile) Abort() error {
+	af.File.Close()
+	return os.Remove(af.File.Name())
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package fasthttp
+
+import (
+	"fmt"
+	"os"
+)
+
+// syncDir syncs dir, so that a renamed file survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("Failed to sync %s: %w", dir, err)
+	}
+	return nil
+}
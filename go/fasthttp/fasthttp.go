@@ -2,141 +2,55 @@
 package fasthttp
 
 import (
-	"fmt"
-	"io"
-	"log"
-	"os"
 	"time"
 
 	"github.com/fasthttp/router"
-	"github.com/parquet-go/parquet-go"
-	"github.com/parquet-go/parquet-go/format"
 	"github.com/valyala/fasthttp"
 )
 
-// RowType contains extracted values from logger.
-type RowType struct {
-	StartTime       time.Time           `parquet:",delta"`
-	Latency         time.Duration       `parquet:",delta"`
-	Protocol        string              `parquet:",dict"`
-	RemoteAddr      string              `parquet:",dict"`
-	Host            string              `parquet:",dict"`
-	Method          string              `parquet:",dict"`
-	URL             string              `parquet:",dict"`
-	Pattern         string              `parquet:",dict"`
-	Status          int                 `parquet:",dict"`
-	RequestSize     int64               `parquet:",delta"`
-	ResponseSize    int64               `parquet:",delta"`
-	RequestHeaders  map[string][]string `parquet:","`
-	ResponseHeaders map[string][]string `parquet:","`
-	Error           *string             `parquet:","`
-}
-
-// A Logger defines parameters for logging.
-type Logger struct {
-	ch       chan RowType
-	exportCh chan string
-	doneCh   chan error
-}
-
-// NewLogger returns a new Logger.
-func NewLogger() *Logger {
-	pl := &Logger{
-		ch:     make(chan RowType, 64),
-		doneCh: make(chan error),
-	}
-	go func() {
-		pl.doneCh <- pl.run()
-	}()
-	return pl
-}
-
-func (pl *Logger) run() error {
-	if pl.ch == nil {
-		log.Fatal("No channel is defined in Logger. Please use NewLogger")
-	}
-	f, err := os.CreateTemp("", ".parquet-logger-*.parquet")
-	if err != nil {
-		log.Fatalf("Failed to create tempfile: %v", err)
-	}
-	os.Remove(f.Name())
-	w := parquet.NewGenericWriter[RowType](f, parquet.Compression(parquet.LookupCompressionCodec(format.Snappy)))
-
-	pl.exportCh = make(chan string)
-	var filename string
-
-L:
-	for {
-		select {
-		case row, ok := <-pl.ch:
-			if !ok {
-				break L
-			}
-			if _, err := w.Write([]RowType{row}); err != nil {
-				log.Printf("Failed to write parquet: %v", err)
-			}
-		case filename = <-pl.exportCh:
-			break L
-		}
-	}
-	if err := w.Close(); err != nil {
-		return fmt.Errorf("Failed to close parquet writer: %w", err)
-	}
-	if filename != "" {
-		out, err := os.Create(filename)
-		if err != nil {
-			return fmt.Errorf("Failed to create %s: %w", filename, err)
-		}
-		if err := f.Sync(); err != nil {
-			return fmt.Errorf("Failed to sync tempfile: %w", err)
-		}
-		if _, err := f.Seek(0, io.SeekStart); err != nil {
-			return fmt.Errorf("Failed to seek tempfile: %w", err)
-		}
-		if _, err := io.Copy(out, f); err != nil {
-			return fmt.Errorf("Failed to copy from %s to %s: %v", f.Name(), out.Name(), err)
-		}
-		if err := out.Close(); err != nil {
-			return fmt.Errorf("Failed to close %s: %w", out.Name(), err)
-		}
-		log.Printf("Succeed to export %s", filename)
-	}
-	if err := f.Close(); err != nil {
-		return fmt.Errorf("Failed to close tempfile: %w", err)
-
-	}
-	return nil
-}
-
-// Export exports parquet file.
-func (pl *Logger) Export(filename string) error {
-	pl.exportCh <- filename
-	go func() {
-		pl.doneCh <- pl.run()
-	}()
-	return <-pl.doneCh
-}
+// adapterName is recorded in the metadata of exported files.
+const adapterName = "fasthttp"
 
-func (pl *Logger) send(row RowType) {
-	select {
-	case pl.ch <- row:
-	default:
-		log.Printf("Failed to add to channel: Capacity limit reached. Consider increasing the channel size.")
-	}
+// RequestInfo contains values of a request which are passed to an extractor.
+type RequestInfo struct {
+	StartTime time.Time
+	Latency   time.Duration
+	Protocol  string
+	// Scheme is http or https.
+	Scheme          string
+	RemoteAddr      string
+	Host            string
+	Method          string
+	URL             string
+	Pattern         string
+	Status          int
+	RequestSize     int64
+	ResponseSize    int64
+	RequestHeaders  map[string][]string
+	ResponseHeaders map[string][]string
+	Error           *string
+	Labels          Labels
+	// Runtime is set by WithRuntimeStats.
+	Runtime *RuntimeStats
+	// Ctx is the context of the served request. It must not be retained
+	// after the extractor returns.
+	Ctx *fasthttp.RequestCtx
 }
 
 // Middleware returns logger middleware.
-func (pl *Logger) Middleware(requestHandler fasthttp.RequestHandler) fasthttp.RequestHandler {
+func (pl *GenericLogger[T]) Middleware(requestHandler fasthttp.RequestHandler) fasthttp.RequestHandler {
 	now := time.Now
 	return fasthttp.RequestHandler(func(ctx *fasthttp.RequestCtx) {
 		// Before
 		start := now()
+		rs := pl.runtimeStart()
 
 		// Next
 		requestHandler(ctx)
 
 		// After
 		latency := now().Sub(start)
+		pl.runtimeEnd(rs)
 		requestHeaders := make(map[string][]string)
 		responseHeaders := make(map[string][]string)
 		ctx.Request.Header.VisitAll(func(key, value []byte) {
@@ -161,10 +75,11 @@ func (pl *Logger) Middleware(requestHandler fasthttp.RequestHandler) fasthttp.Re
 		if !ok {
 			routePath = ""
 		}
-		row := RowType{
+		info := RequestInfo{
 			StartTime:       start,
 			Latency:         latency,
 			Protocol:        string(ctx.Request.Header.Protocol()),
+			Scheme:          string(ctx.URI().Scheme()),
 			RemoteAddr:      ctx.RemoteAddr().String(),
 			Host:            string(ctx.Host()),
 			Method:          string(ctx.Method()),
@@ -175,7 +90,9 @@ func (pl *Logger) Middleware(requestHandler fasthttp.RequestHandler) fasthttp.Re
 			ResponseSize:    int64(len(ctx.Response.String())),
 			RequestHeaders:  requestHeaders,
 			ResponseHeaders: responseHeaders,
+			Runtime:         rs,
+			Ctx:             ctx,
 		}
-		pl.send(row)
+		pl.log(info)
 	})
 }
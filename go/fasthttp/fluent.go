@@ -0,0 +1,239 @@
+package fasthttp
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+)
+
+// FluentConfig defines where and how FluentSink forwards logs.
+type FluentConfig struct {
+	// Network is "tcp" or "unix". The default is "tcp".
+	Network string
+	// Address is the address of fluentd or fluent-bit. The default is
+	// localhost:24224.
+	Address string
+	// Tag is the tag of records. The default is "parquetlogger".
+	Tag string
+	// RequireAck waits for the server to acknowledge every message, and
+	// resends a message which is not acknowledged.
+	RequireAck bool
+	// Timeout bounds connecting, writing a message and waiting for its ack.
+	// The default is 10 seconds.
+	Timeout time.Duration
+	// BatchConfig defines buffering and reconnects. The default
+	// MaxBatchRows is 512. Client is not used.
+	BatchConfig
+}
+
+// FluentSink forwards rows to fluentd or fluent-bit in the Forward mode of
+// the Fluent Forward protocol. Messages are sent in background, and the
+// connection is reopened when it fails.
+type FluentSink struct {
+	cfg  FluentConfig
+	q    *batchQueue[[]byte]
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+var _ Sink[RowType] = (*FluentSink)(nil)
+
+// NewFluentSink returns a Sink which forwards rows to a Forward input.
+func NewFluentSink(cfg FluentConfig) *FluentSink {
+	if cfg.Network == "" {
+		cfg.Network = "tcp"
+	}
+	if cfg.Address == "" {
+		cfg.Address = "localhost:24224"
+	}
+	if cfg.Tag == "" {
+		cfg.Tag = "parquetlogger"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	cfg.setDefaults(512)
+	s := &FluentSink{cfg: cfg}
+	s.q = newBatchQueue(&s.cfg.BatchConfig, s.send)
+	return s
+}
+
+// Write implements Sink.
+func (s *FluentSink) Write(rows []RowType) error {
+	for i := range rows {
+		if err := s.q.add(appendFluentEntry(nil, &rows[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush queues the pending rows to be sent.
+func (s *FluentSink) Flush() error {
+	return s.q.flush()
+}
+
+// Close sends the pending rows, waits for queued messages and closes the
+// connection.
+func (s *FluentSink) Close() error {
+	err := s.q.close()
+	s.disconnect()
+	return err
+}
+
+func (s *FluentSink) send(entries [][]byte) error {
+	var chunk string
+	if s.cfg.RequireAck {
+		id := make([]byte, 16)
+		rand.Read(id)
+		chunk = base64.StdEncoding.EncodeToString(id)
+	}
+	msg := appendFluentMessage(nil, s.cfg.Tag, entries, chunk)
+	err := s.cfg.retry(func() (time.Duration, error) {
+		if err := s.write(msg, chunk); err != nil {
+			s.disconnect()
+			return 0, err
+		}
+		return 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to forward %d logs to %s: %w", len(entries), s.cfg.Address, err)
+	}
+	return nil
+}
+
+// write sends msg and waits for the ack of chunk unless chunk is empty.
+func (s *FluentSink) write(msg []byte, chunk string) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.cfg.Network, s.cfg.Address, s.cfg.Timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+		s.r = bufio.NewReader(conn)
+	}
+	if err := s.conn.SetDeadline(time.Now().Add(s.cfg.Timeout)); err != nil {
+		return err
+	}
+	if _, err := s.conn.Write(msg); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+	resp, err := readMsgpack(s.r)
+	if err != nil {
+		return fmt.Errorf("Failed to read ack: %w", err)
+	}
+	if m, ok := resp.(map[string]any); !ok || m["ack"] != chunk {
+		return fmt.Errorf("Unexpected ack %v, want %s", resp, chunk)
+	}
+	return nil
+}
+
+func (s *FluentSink) disconnect() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// appendFluentMessage appends a message of the Forward mode,
+// [tag, [entry...], option].
+func appendFluentMessage(b []byte, tag string, entries [][]byte, chunk string) []byte {
+	b = appendMsgpackArrayHeader(b, 3)
+	b = appendMsgpackString(b, tag)
+	b = appendMsgpackArrayHeader(b, len(entries))
+	for _, e := range entries {
+		b = append(b, e...)
+	}
+	if chunk == "" {
+		b = appendMsgpackMapHeader(b, 1)
+	} else {
+		b = appendMsgpackMapHeader(b, 2)
+		b = appendMsgpackString(b, "chunk")
+		b = appendMsgpackString(b, chunk)
+	}
+	b = appendMsgpackString(b, "size")
+	return appendMsgpackInt(b, int64(len(entries)))
+}
+
+// appendFluentEntry appends [time, record] of row. The time is StartTime and
+// the record has the other columns. Empty labels are omitted.
+func appendFluentEntry(b []byte, row *RowType) []byte {
+	b = appendMsgpackArrayHeader(b, 2)
+	b = appendMsgpackEventTime(b, row.StartTime)
+
+	type field struct {
+		key   string
+		value string
+	}
+	var labels []field
+	for _, f := range []field{
+		{"Instance", row.Instance},
+		{"Service", row.Service},
+		{"Version", row.Version},
+		{"Environment", row.Environment},
+	} {
+		if f.value != "" {
+			labels = append(labels, f)
+		}
+	}
+	b = appendMsgpackMapHeader(b, 14+len(labels))
+	b = appendMsgpackString(b, "Latency")
+	b = appendMsgpackInt(b, int64(row.Latency))
+	for _, f := range []field{
+		{"Protocol", row.Protocol},
+		{"Scheme", row.Scheme},
+		{"RemoteAddr", row.RemoteAddr},
+		{"Host", row.Host},
+		{"Method", row.Method},
+		{"URL", row.URL},
+		{"Pattern", row.Pattern},
+	} {
+		b = appendMsgpackString(b, f.key)
+		b = appendMsgpackString(b, f.value)
+	}
+	b = appendMsgpackString(b, "Status")
+	b = appendMsgpackInt(b, int64(row.Status))
+	b = appendMsgpackString(b, "RequestSize")
+	b = appendMsgpackInt(b, row.RequestSize)
+	b = appendMsgpackString(b, "ResponseSize")
+	b = appendMsgpackInt(b, row.ResponseSize)
+	b = appendMsgpackString(b, "RequestHeaders")
+	b = appendMsgpackHeaders(b, row.RequestHeaders)
+	b = appendMsgpackString(b, "ResponseHeaders")
+	b = appendMsgpackHeaders(b, row.ResponseHeaders)
+	b = appendMsgpackString(b, "Error")
+	if row.Error != nil {
+		b = appendMsgpackString(b, *row.Error)
+	} else {
+		b = appendMsgpackNil(b)
+	}
+	for _, f := range labels {
+		b = appendMsgpackString(b, f.key)
+		b = appendMsgpackString(b, f.value)
+	}
+	return b
+}
+
+func appendMsgpackHeaders(b []byte, h map[string][]string) []byte {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	b = appendMsgpackMapHeader(b, len(keys))
+	for _, k := range keys {
+		b = appendMsgpackString(b, k)
+		b = appendMsgpackArrayHeader(b, len(h[k]))
+		for _, v := range h[k] {
+			b = appendMsgpackString(b, v)
+		}
+	}
+	return b
+}
@@ -0,0 +1,163 @@
+package fasthttp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// StartTimeEncoding is the encoding of StartTime in JSON lines.
+type StartTimeEncoding int
+
+const (
+	// StartTimeNginx is the end of the request in seconds since the epoch
+	// with millisecond precision, the same as $msec of nginx/log.js.
+	StartTimeNginx StartTimeEncoding = iota
+	// StartTimeRFC3339Nano is the start of the request in RFC 3339 format.
+	StartTimeRFC3339Nano
+	// StartTimeUnixNano is the start of the request in nanoseconds since
+	// the epoch.
+	StartTimeUnixNano
+)
+
+// LatencyEncoding is the encoding of Latency in JSON lines.
+type LatencyEncoding int
+
+const (
+	// LatencySeconds is seconds with millisecond precision, the same as
+	// $request_time of nginx/log.js.
+	LatencySeconds LatencyEncoding = iota
+	// LatencyNanoseconds is nanoseconds, the same as RowType in parquet.
+	LatencyNanoseconds
+)
+
+// JSONLConfig defines how JSONLSink encodes rows. The default is
+// compatible with nginx/log.js and sql/duckdb/nginx.sql.
+type JSONLConfig struct {
+	StartTime StartTimeEncoding
+	Latency   LatencyEncoding
+}
+
+// jsonRow has the fields of nginx/log.js in the same order. Protocol is
+// the version without "HTTP/" like r.httpVersion, and RemoteAddr has no
+// port like $remote_addr.
+type jsonRow struct {
+	StartTime       any
+	Latency         any
+	Protocol        string
+	RemoteAddr      string
+	Host            string
+	Method          string
+	URL             string
+	Pattern         string
+	Status          int
+	Error           *string
+	RequestSize     int64
+	ResponseSize    int64
+	RequestHeaders  map[string][]string
+	ResponseHeaders map[string][]string
+	Instance        string `json:",omitempty"`
+	Service         string `json:",omitempty"`
+	Version         string `json:",omitempty"`
+	Environment     string `json:",omitempty"`
+}
+
+// JSONLSink writes rows as JSON lines.
+type JSONLSink struct {
+	cfg JSONLConfig
+	out io.Writer
+	bw  *bufio.Writer
+	enc *json.Encoder
+}
+
+var _ Sink[RowType] = (*JSONLSink)(nil)
+
+// NewJSONLSink returns a Sink which writes a JSON object per line into out.
+// out is closed with the Sink if it is an io.Closer.
+func NewJSONLSink(out io.Writer, cfg JSONLConfig) *JSONLSink {
+	bw := bufio.NewWriter(out)
+	return &JSONLSink{cfg: cfg, out: out, bw: bw, enc: json.NewEncoder(bw)}
+}
+
+// Write implements Sink.
+func (s *JSONLSink) Write(rows []RowType) error {
+	for i := range rows {
+		if err := s.enc.Encode(s.jsonRow(&rows[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *JSONLSink) jsonRow(row *RowType) *jsonRow {
+	r := &jsonRow{
+		Protocol:        strings.TrimPrefix(row.Protocol, "HTTP/"),
+		RemoteAddr:      remoteHost(row.RemoteAddr),
+		Host:            row.Host,
+		Method:          row.Method,
+		URL:             row.URL,
+		Pattern:         row.Pattern,
+		Status:          row.Status,
+		Error:           row.Error,
+		RequestSize:     row.RequestSize,
+		ResponseSize:    row.ResponseSize,
+		RequestHeaders:  row.RequestHeaders,
+		ResponseHeaders: row.ResponseHeaders,
+		Instance:        row.Instance,
+		Service:         row.Service,
+		Version:         row.Version,
+		Environment:     row.Environment,
+	}
+	switch s.cfg.StartTime {
+	case StartTimeRFC3339Nano:
+		r.StartTime = row.StartTime.Format(time.RFC3339Nano)
+	case StartTimeUnixNano:
+		r.StartTime = row.StartTime.UnixNano()
+	default:
+		r.StartTime = millis(row.StartTime.Add(row.Latency).UnixMilli())
+	}
+	switch s.cfg.Latency {
+	case LatencyNanoseconds:
+		r.Latency = row.Latency.Nanoseconds()
+	default:
+		r.Latency = millis(row.Latency.Milliseconds())
+	}
+	return r
+}
+
+// remoteHost strips the port from addr if any.
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// millis formats milliseconds as seconds with three decimal places.
+func millis(ms int64) json.Number {
+	sign := ""
+	if ms < 0 {
+		sign, ms = "-", -ms
+	}
+	return json.Number(fmt.Sprintf("%s%d.%03d", sign, ms/1000, ms%1000))
+}
+
+// Flush implements Sink.
+func (s *JSONLSink) Flush() error {
+	return s.bw.Flush()
+}
+
+// Close flushes buffered lines and closes the destination.
+func (s *JSONLSink) Close() error {
+	err := s.bw.Flush()
+	if c, ok := s.out.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,555 @@
+package fasthttp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+var (
+	// ErrExportInProgress is returned by Export while another Export is running.
+	ErrExportInProgress = errors.New("Export is already in progress")
+	// ErrClosed is returned when the Logger is closed.
+	ErrClosed = errors.New("Logger is closed")
+	// ErrNoRotateDir is reported when WithRetention is given without
+	// WithRotateDir. The retention is disabled then.
+	ErrNoRotateDir = errors.New("WithRetention requires WithRotateDir")
+	// ErrNoTempfile is returned by Export, ExportTo and Rotate when the
+	// Logger has no tempfile, e.g. with WithoutTempfile.
+	ErrNoTempfile = errors.New("Logger has no tempfile")
+)
+
+// state is the lifecycle state of a Logger.
+//
+//	starting -> running <-> exporting
+//	    \          |           /
+//	     `------> closed <----'
+type state int
+
+const (
+	stateStarting state = iota
+	stateRunning
+	stateExporting
+	stateClosed
+)
+
+// A GenericLogger defines parameters for logging. T is the type of a row,
+// which must be a struct that parquet-go can write.
+type GenericLogger[T any] struct {
+	extract  func(RequestInfo) T
+	hooks    atomic.Pointer[[]func(*T) bool]
+	sinks    sinkSet[T]
+	sinkCh   chan Sink[T]
+	schema   *parquet.Schema
+	ch       chan T
+	sampleCh chan RuntimeSample
+	exportCh chan exportRequest
+	quitCh   chan struct{}
+	doneCh   chan struct{}
+	cfg      config
+	limiter  rateLimiter
+	meta     map[string]string
+	dropped  atomic.Int64
+	seq      atomic.Int64
+	// droppedTotal counts dropped rows since NewLogger, while dropped is
+	// reset by every export.
+	droppedTotal atomic.Int64
+
+	retentionCh chan struct{}
+	retention   retentionCounters
+	// dirMu keeps the retention from removing a directory in which Rotate
+	// is creating a file.
+	dirMu sync.Mutex
+
+	mu    sync.Mutex
+	state state
+}
+
+// A Logger is a GenericLogger which writes RowType.
+type Logger = GenericLogger[RowType]
+
+// NewLogger returns a new Logger which writes RowType.
+// Errors while preparing the tempfile are reported through OnError and
+// returned by the next Export.
+func NewLogger(opts ...Option) *Logger {
+	return NewGenericLogger(DefaultExtractor, opts...)
+}
+
+// NewGenericLogger returns a new GenericLogger which writes rows returned by
+// extract. extract is called in the request goroutine after the handler.
+// It panics if extract is nil.
+func NewGenericLogger[T any](extract func(RequestInfo) T, opts ...Option) *GenericLogger[T] {
+	if extract == nil {
+		panic("No extract is given to NewGenericLogger")
+	}
+	pl := &GenericLogger[T]{
+		extract:  extract,
+		schema:   parquet.SchemaOf(new(T)),
+		ch:       make(chan T, 64),
+		sinkCh:   make(chan Sink[T]),
+		exportCh: make(chan exportRequest),
+		quitCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+		cfg: config{
+			rotateDir:     os.TempDir(),
+			overwrite:     true,
+			logger:        slog.Default(),
+			flushInterval: time.Second,
+		},
+	}
+	for _, opt := range opts {
+		opt(&pl.cfg)
+	}
+	if pl.cfg.labels.Instance == "" {
+		pl.cfg.labels.Instance, _ = os.Hostname()
+	}
+	pl.meta = pl.staticMetadata()
+	pl.seq.Store(time.Now().UnixMilli())
+	if pl.cfg.runtimeSampleInterval > 0 {
+		pl.sampleCh = make(chan RuntimeSample, 16)
+		go pl.runRuntimeSampler()
+	}
+	go pl.run()
+	if pl.cfg.retention.enabled() {
+		if pl.cfg.rotateDirSet {
+			pl.retentionCh = make(chan struct{}, 1)
+			go pl.runRetention()
+		} else {
+			pl.reportError(ErrNoRotateDir)
+		}
+	}
+	return pl
+}
+
+// transition moves the Logger to next if it is in one of from.
+func (pl *GenericLogger[T]) transition(next state, from ...state) bool {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	for _, s := range from {
+		if pl.state == s {
+			pl.state = next
+			return true
+		}
+	}
+	return false
+}
+
+func (pl *GenericLogger[T]) run() {
+	defer close(pl.doneCh)
+
+	tf, err := pl.openTempfile()
+	st, stErr := pl.openSampleTempfile()
+	pl.transition(stateRunning, stateStarting)
+
+	var flushCh <-chan time.Time
+	rows := make([]T, 0, maxBatchRows)
+	for {
+		select {
+		case row := <-pl.ch:
+			rows = pl.receive(append(rows[:0], row))
+			if len(rows) == 0 {
+				continue
+			}
+			if err != nil && err != ErrNoTempfile {
+				pl.drop(int64(len(rows)))
+				pl.reportError(fmt.Errorf("Failed to write parquet: %w", err))
+			} else if err == nil {
+				if n, err := tf.write(rows); err != nil {
+					pl.drop(int64(len(rows) - n))
+					pl.reportError(fmt.Errorf("Failed to write parquet: %w", err))
+				}
+			}
+			pl.sinks.write(rows, pl.reportError)
+		case sample := <-pl.sampleCh:
+			if stErr == nil {
+				if _, err := st.write([]RuntimeSample{sample}); err != nil {
+					pl.reportError(fmt.Errorf("Failed to write runtime sample: %w", err))
+				}
+			}
+		case sink := <-pl.sinkCh:
+			pl.sinks = append(pl.sinks, sink)
+			if pl.noTempfile() != (err == ErrNoTempfile) {
+				if err == nil {
+					tf.Close()
+				}
+				tf, err = pl.openTempfile()
+				if st != nil {
+					st.Close()
+				}
+				st, stErr = pl.openSampleTempfile()
+			}
+			if flushCh == nil && pl.cfg.flushInterval > 0 {
+				ticker := time.NewTicker(pl.cfg.flushInterval)
+				defer ticker.Stop()
+				flushCh = ticker.C
+			}
+		case <-flushCh:
+			pl.sinks.flush(pl.reportError)
+		case req := <-pl.exportCh:
+			exportErr := err
+			if err != nil {
+				tf, err = pl.openTempfile()
+			} else if exportErr = req.ctx.Err(); exportErr == nil {
+				exportErr = pl.export(tf, req)
+				prev := tf
+				tf, err = pl.openTempfile()
+				keepRows(prev, tf, exportErr, pl.reportError)
+			}
+			if pl.sampleCh != nil && req.openRuntime != nil {
+				if stErr != nil {
+					st, stErr = pl.openSampleTempfile()
+				} else {
+					runtimeErr := pl.exportRuntime(st, req)
+					if exportErr == nil {
+						exportErr = runtimeErr
+					}
+					prev := st
+					st, stErr = pl.openSampleTempfile()
+					keepRows(prev, st, runtimeErr, pl.reportError)
+				}
+			}
+			// The Logger accepts the next Export before the caller returns.
+			pl.transition(stateRunning, stateExporting)
+			req.errCh <- exportErr
+		case <-pl.quitCh:
+			if err == nil {
+				tf.Close()
+			}
+			if st != nil {
+				st.Close()
+			}
+			pl.sinks.close(pl.reportError)
+			return
+		}
+	}
+}
+
+// noTempfile reports whether the Logger writes rows only to the sinks,
+// either by WithoutTempfile or because a CollectorSink is the only sink.
+func (pl *GenericLogger[T]) noTempfile() bool {
+	return pl.cfg.noTempfile || pl.sinks.collectorOnly()
+}
+
+// openTempfile opens the tempfile of Export. It returns ErrNoTempfile if
+// the tempfile is disabled.
+func (pl *GenericLogger[T]) openTempfile() (*tempfile[T], error) {
+	if pl.noTempfile() {
+		return nil, ErrNoTempfile
+	}
+	tf, err := openTempfile[T](&pl.cfg, pl.schema)
+	if err != nil {
+		pl.reportError(err)
+	}
+	return tf, err
+}
+
+// openSampleTempfile opens the tempfile of WithRuntimeSampler. It returns
+// nil if the sampler is disabled.
+func (pl *GenericLogger[T]) openSampleTempfile() (*tempfile[RuntimeSample], error) {
+	if pl.sampleCh == nil {
+		return nil, nil
+	}
+	if pl.noTempfile() {
+		return nil, ErrNoTempfile
+	}
+	st, err := openTempfile[RuntimeSample](&pl.cfg, runtimeSampleSchema)
+	if err != nil {
+		pl.reportError(err)
+	}
+	return st, err
+}
+
+// receive appends rows waiting in the channel to rows up to maxBatchRows,
+// and drops rows vetoed by hooks in the writer goroutine.
+func (pl *GenericLogger[T]) receive(rows []T) []T {
+	for len(rows) < maxBatchRows {
+		select {
+		case row := <-pl.ch:
+			rows = append(rows, row)
+			continue
+		default:
+		}
+		break
+	}
+	if !pl.cfg.hooksInWriter {
+		return rows
+	}
+	kept := rows[:0]
+	for i := range rows {
+		if pl.runHooks(&rows[i]) {
+			kept = append(kept, rows[i])
+		}
+	}
+	return kept
+}
+
+// rowWriter is implemented by parquet.GenericWriter and parquet.SortingWriter.
+type rowWriter[T any] interface {
+	Write(rows []T) (int, error)
+	Flush() error
+	Close() error
+	SetKeyValueMetadata(key, value string)
+}
+
+// tempfile is an unlinked file which holds rows until they are exported.
+type tempfile[T any] struct {
+	f *os.File
+	rowFile[T]
+}
+
+// rowFile writes rows into a parquet file.
+type rowFile[T any] struct {
+	w rowWriter[T]
+	// flushEvery is the number of rows in a row group, or 0 to let the
+	// writer decide.
+	flushEvery int64
+	stats      fileStats
+}
+
+// fileStats describes rows written into a file.
+type fileStats struct {
+	rows        int64
+	first, last time.Time
+}
+
+// add counts row, which is a pointer to a row.
+func (st *fileStats) add(row any) {
+	if t, ok := timeField(row, "StartTime"); ok {
+		if st.rows == 0 || t.Before(st.first) {
+			st.first = t
+		}
+		if st.rows == 0 || t.After(st.last) {
+			st.last = t
+		}
+	}
+	st.rows++
+}
+
+func openTempfile[T any](cfg *config, schema *parquet.Schema) (*tempfile[T], error) {
+	f, err := os.CreateTemp("", ".parquet-logger-*.parquet")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create tempfile: %w", err)
+	}
+	os.Remove(f.Name())
+	return &tempfile[T]{f: f, rowFile: newRowFile[T](cfg, schema, f)}, nil
+}
+
+func newRowFile[T any](c *config, schema *parquet.Schema, out io.Writer) rowFile[T] {
+	opts := c.writerOptions(schema)
+	if c.sortRows > 0 && hasColumn(schema, "StartTime") {
+		return rowFile[T]{
+			w:          parquet.NewSortingWriter[T](out, c.sortRows, opts...),
+			flushEvery: c.sortRows,
+		}
+	}
+	return rowFile[T]{w: parquet.NewGenericWriter[T](out, opts...)}
+}
+
+func hasColumn(schema *parquet.Schema, name string) bool {
+	_, ok := schema.Lookup(name)
+	return ok
+}
+
+// writerOptions returns options of parquet writers. Options about columns
+// which schema does not have are ignored.
+func (c *config) writerOptions(schema *parquet.Schema) []parquet.WriterOption {
+	opts := []parquet.WriterOption{
+		schema,
+		parquet.Compression(parquet.LookupCompressionCodec(format.Snappy)),
+	}
+	if c.sortRows > 0 && hasColumn(schema, "StartTime") {
+		opts = append(opts,
+			parquet.DataPageStatistics(true),
+			parquet.SortingWriterConfig(parquet.SortingColumns(parquet.Ascending("StartTime"))),
+		)
+	}
+	if c.bloomFilters {
+		var filters []parquet.BloomFilterColumn
+		for _, name := range []string{"Pattern", "RemoteAddr"} {
+			if hasColumn(schema, name) {
+				filters = append(filters, parquet.SplitBlockFilter(10, name))
+			}
+		}
+		opts = append(opts, parquet.BloomFilters(filters...))
+	}
+	return opts
+}
+
+// write writes rows and returns the number of rows written.
+func (rf *rowFile[T]) write(rows []T) (int, error) {
+	for i := range rows {
+		if _, err := rf.w.Write(rows[i : i+1]); err != nil {
+			return i, err
+		}
+		rf.stats.add(&rows[i])
+		if rf.flushEvery > 0 && rf.stats.rows%rf.flushEvery == 0 {
+			if err := rf.w.Flush(); err != nil {
+				return i + 1, err
+			}
+		}
+	}
+	return len(rows), nil
+}
+
+// Write implements Sink.
+func (rf *rowFile[T]) Write(rows []T) error {
+	_, err := rf.write(rows)
+	return err
+}
+
+// Flush implements Sink.
+func (rf *rowFile[T]) Flush() error {
+	return rf.w.Flush()
+}
+
+// keepRows closes prev, which is a finished parquet file. If the export of
+// prev failed, its rows are copied into next, so that the next export
+// retries them.
+func keepRows[T any](prev, next *tempfile[T], exportErr error, report func(error)) {
+	defer prev.f.Close()
+	if exportErr == nil {
+		return
+	}
+	if next == nil {
+		report(fmt.Errorf("Failed to keep %d rows of the failed export: no tempfile", prev.stats.rows))
+		return
+	}
+	r := parquet.NewGenericReader[T](prev.f)
+	defer r.Close()
+	rows := make([]T, maxBatchRows)
+	for {
+		n, err := r.Read(rows)
+		if n > 0 {
+			if _, err := next.write(rows[:n]); err != nil {
+				report(fmt.Errorf("Failed to keep rows of the failed export: %w", err))
+				return
+			}
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			report(fmt.Errorf("Failed to keep rows of the failed export: %w", err))
+			return
+		}
+	}
+}
+
+// Close discards the tempfile.
+func (tf *tempfile[T]) Close() error {
+	tf.w.Close()
+	return tf.f.Close()
+}
+
+// Close stops the Logger and discards rows which are not exported.
+// It waits for a running Export to finish.
+func (pl *GenericLogger[T]) Close() error {
+	if pl.ch == nil {
+		return ErrNotInitialized
+	}
+	pl.mu.Lock()
+	if pl.state == stateClosed {
+		pl.mu.Unlock()
+		return ErrClosed
+	}
+	pl.state = stateClosed
+	pl.mu.Unlock()
+	close(pl.quitCh)
+	<-pl.doneCh
+	return nil
+}
+
+// log extracts a row from info and sends it to the writer goroutine.
+func (pl *GenericLogger[T]) log(info RequestInfo) {
+	if pl.ch == nil {
+		pl.reportError(ErrNotInitialized)
+		return
+	}
+	info.Labels = pl.cfg.labels
+	row := pl.extract(info)
+	if !pl.cfg.hooksInWriter && !pl.runHooks(&row) {
+		return
+	}
+	pl.send(row)
+}
+
+// AddSink adds a Sink which receives rows logged after AddSink returns in
+// addition to the tempfile of Export. Errors of a Sink are reported through
+// OnError and affect neither other sinks nor Export. The Logger closes the
+// Sink when it is closed.
+func (pl *GenericLogger[T]) AddSink(sink Sink[T]) error {
+	if pl.ch == nil {
+		return ErrNotInitialized
+	}
+	if s, ok := sink.(droppedSetter); ok {
+		s.setDropped(pl.droppedTotal.Load)
+	}
+	select {
+	case pl.sinkCh <- sink:
+		return nil
+	case <-pl.doneCh:
+		return ErrClosed
+	}
+}
+
+// droppedSetter is implemented by sinks which report the number of dropped
+// rows, such as Metrics.
+type droppedSetter interface {
+	setDropped(dropped func() int64)
+}
+
+// drop counts n dropped rows.
+func (pl *GenericLogger[T]) drop(n int64) {
+	pl.dropped.Add(n)
+	pl.droppedTotal.Add(n)
+}
+
+// OnRow adds a hook which is called with every row before it is written.
+// A hook may modify the row, e.g. to enrich or redact it, and returns false
+// to drop it. Hooks are called in the order they are added.
+func (pl *GenericLogger[T]) OnRow(hook func(row *T) bool) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	var hooks []func(*T) bool
+	if p := pl.hooks.Load(); p != nil {
+		hooks = append(hooks, *p...)
+	}
+	hooks = append(hooks, hook)
+	pl.hooks.Store(&hooks)
+}
+
+// runHooks calls the hooks with row and reports whether row is kept.
+func (pl *GenericLogger[T]) runHooks(row *T) bool {
+	p := pl.hooks.Load()
+	if p == nil {
+		return true
+	}
+	for _, hook := range *p {
+		if !hook(row) {
+			return false
+		}
+	}
+	return true
+}
+
+func (pl *GenericLogger[T]) send(row T) {
+	select {
+	case <-pl.doneCh:
+		return
+	default:
+	}
+	select {
+	case pl.ch <- row:
+	default:
+		pl.drop(1)
+		pl.reportError(ErrChannelFull)
+	}
+}
@@ -0,0 +1,188 @@
+package fasthttp
+
+import (
+	"log/slog"
+	"time"
+)
+
+type config struct {
+	rotateDir     string
+	rotateDirSet  bool
+	writerFactory WriterFactory
+	overwrite     bool
+	logger        *slog.Logger
+	onError       func(error)
+	metadata      map[string]string
+	labels        Labels
+	sortRows      int64
+	bloomFilters  bool
+	partitionKeys []PartitionKey
+	retention     Retention
+	hooksInWriter bool
+	flushInterval time.Duration
+	noTempfile    bool
+	onRotate      func(filename string)
+	runtimeStats  bool
+	// runtimeSampleInterval is the interval of WithRuntimeSampler, or 0.
+	runtimeSampleInterval time.Duration
+}
+
+// An Option configures a Logger.
+type Option func(*config)
+
+// WithRotateDir sets the directory where Rotate writes files.
+// The default is os.TempDir().
+func WithRotateDir(dir string) Option {
+	return func(c *config) {
+		c.rotateDir = dir
+		c.rotateDirSet = true
+	}
+}
+
+// WithWriterFactory sets the factory which opens the destination of
+// Rotate instead of a file in the rotate directory.
+func WithWriterFactory(factory WriterFactory) Option {
+	return func(c *config) {
+		c.writerFactory = factory
+	}
+}
+
+// WithOnRotate sets a callback which is called with the path of every file
+// which Rotate completes in the rotate directory. It is called in the writer
+// goroutine, so it should return quickly, e.g. by S3Uploader.Enqueue.
+func WithOnRotate(onRotate func(filename string)) Option {
+	return func(c *config) {
+		c.onRotate = onRotate
+	}
+}
+
+// WithOverwrite sets whether Export and Rotate replace an existing file.
+// If false, they fail with an error wrapping fs.ErrExist. The default is true.
+func WithOverwrite(overwrite bool) Option {
+	return func(c *config) {
+		c.overwrite = overwrite
+	}
+}
+
+// WithLogger sets the logger for diagnostic messages.
+// The default is slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithOnError sets a callback which is called with errors that happen
+// outside of a method call, such as a dropped or failed row. Like the log
+// messages, an error with the same text is passed at most once in 10
+// seconds.
+func WithOnError(onError func(error)) Option {
+	return func(c *config) {
+		c.onError = onError
+	}
+}
+
+// WithHooksInWriter calls the hooks of OnRow in the writer goroutine
+// instead of the request goroutine. Slow hooks then no longer add to the
+// latency of requests, but they may fill the channel.
+func WithHooksInWriter() Option {
+	return func(c *config) {
+		c.hooksInWriter = true
+	}
+}
+
+// WithRuntimeStats sets RuntimeStats of RequestInfo, which NewLogger writes
+// into the Goroutines, HeapInUse, GCCycleStart and GCCycleEnd columns.
+func WithRuntimeStats() Option {
+	return func(c *config) {
+		c.runtimeStats = true
+	}
+}
+
+// WithRuntimeSampler records RuntimeSample every interval, a second by
+// default, in background. Samples are exported as parquet into a companion
+// file by Export and Rotate: runtime-log.parquet for log.parquet by Export,
+// and runtime/log-<time>.parquet in the rotate directory by Rotate. ExportTo
+// does not export samples.
+func WithRuntimeSampler(interval time.Duration) Option {
+	return func(c *config) {
+		if interval <= 0 {
+			interval = time.Second
+		}
+		c.runtimeSampleInterval = interval
+	}
+}
+
+// WithoutTempfile writes rows only to the sinks of AddSink and not into the
+// tempfile of Export. Export, ExportTo and Rotate then return ErrNoTempfile.
+func WithoutTempfile() Option {
+	return func(c *config) {
+		c.noTempfile = true
+	}
+}
+
+// WithFlushInterval sets the interval of flushing the sinks of AddSink.
+// The default is a second.
+func WithFlushInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.flushInterval = interval
+	}
+}
+
+// WithMetadata adds key/value metadata to every exported file.
+// Keys written by the Logger itself take precedence.
+func WithMetadata(metadata map[string]string) Option {
+	return func(c *config) {
+		c.metadata = metadata
+	}
+}
+
+// WithLabels sets the constant columns of every row.
+// The default Instance is the hostname.
+func WithLabels(labels Labels) Option {
+	return func(c *config) {
+		c.labels = labels
+	}
+}
+
+// WithSortByStartTime sorts rows of each row group by StartTime, where a
+// row group holds rowsPerGroup rows. It also writes page statistics and
+// declares the sorting column, so that time range queries can skip row
+// groups and pages.
+func WithSortByStartTime(rowsPerGroup int64) Option {
+	return func(c *config) {
+		c.sortRows = rowsPerGroup
+	}
+}
+
+// WithBloomFilters writes bloom filters of Pattern and RemoteAddr.
+func WithBloomFilters() Option {
+	return func(c *config) {
+		c.bloomFilters = true
+	}
+}
+
+// WithHiveLayout makes Rotate split rows into hive partitioned files such as
+// dt=2006-01-02/hour=15/part-<instance>-<seq>.parquet under the rotate
+// directory, or names given to the WriterFactory. seq increases
+// monotonically across restarts.
+// A Rotate writes at most 256 partitions. Rows of further partitions go to
+// the partition whose values are all __HIVE_DEFAULT_PARTITION__.
+// All files are written before any is committed, by rename or by Close of
+// the WriterFactory's writer. If committing a file fails, the files
+// committed before it are kept, and all rows are kept for the next Rotate,
+// so the rows of those files are written again.
+func WithHiveLayout(keys ...PartitionKey) Option {
+	return func(c *config) {
+		c.partitionKeys = keys
+	}
+}
+
+// WithRetention deletes old files in the rotate directory in background
+// after each Export. It requires WithRotateDir, so that files in a shared
+// directory such as os.TempDir() are never deleted.
+func WithRetention(retention Retention) Option {
+	return func(c *config) {
+		c.retention = retention
+	}
+}
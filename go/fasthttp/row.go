@@ -0,0 +1,100 @@
+package fasthttp
+
+import (
+	"reflect"
+	"time"
+)
+
+// RowType contains extracted values from logger.
+type RowType struct {
+	StartTime       time.Time           `parquet:",delta"`
+	Latency         time.Duration       `parquet:",delta"`
+	Protocol        string              `parquet:",dict"`
+	Scheme          string              `parquet:",dict"`
+	RemoteAddr      string              `parquet:",dict"`
+	Host            string              `parquet:",dict"`
+	Method          string              `parquet:",dict"`
+	URL             string              `parquet:",dict"`
+	Pattern         string              `parquet:",dict"`
+	Status          int                 `parquet:",dict"`
+	RequestSize     int64               `parquet:",delta"`
+	ResponseSize    int64               `parquet:",delta"`
+	RequestHeaders  map[string][]string `parquet:","`
+	ResponseHeaders map[string][]string `parquet:","`
+	Error           *string             `parquet:","`
+	Instance        string              `parquet:",dict"`
+	Service         string              `parquet:",dict"`
+	Version         string              `parquet:",dict"`
+	Environment     string              `parquet:",dict"`
+	// Runtime columns are set by WithRuntimeStats.
+	Goroutines   *int64 `parquet:","`
+	HeapInUse    *int64 `parquet:","`
+	GCCycleStart *int64 `parquet:","`
+	GCCycleEnd   *int64 `parquet:","`
+}
+
+// Labels are constant values set into every row, which tell rows apart
+// when logs from several processes are merged.
+type Labels struct {
+	// Instance identifies the process. The default is the hostname.
+	Instance    string
+	Service     string
+	Version     string
+	Environment string
+}
+
+// DefaultExtractor returns a RowType of info. It is the extractor of NewLogger.
+func DefaultExtractor(info RequestInfo) RowType {
+	row := RowType{
+		StartTime:       info.StartTime,
+		Latency:         info.Latency,
+		Protocol:        info.Protocol,
+		Scheme:          info.Scheme,
+		RemoteAddr:      info.RemoteAddr,
+		Host:            info.Host,
+		Method:          info.Method,
+		URL:             info.URL,
+		Pattern:         info.Pattern,
+		Status:          info.Status,
+		RequestSize:     info.RequestSize,
+		ResponseSize:    info.ResponseSize,
+		RequestHeaders:  info.RequestHeaders,
+		ResponseHeaders: info.ResponseHeaders,
+		Error:           info.Error,
+		Instance:        info.Labels.Instance,
+		Service:         info.Labels.Service,
+		Version:         info.Labels.Version,
+		Environment:     info.Labels.Environment,
+	}
+	if rs := info.Runtime; rs != nil {
+		row.Goroutines = &rs.Goroutines
+		row.HeapInUse = &rs.HeapInUse
+		row.GCCycleStart = &rs.GCCycleStart
+		row.GCCycleEnd = &rs.GCCycleEnd
+	}
+	return row
+}
+
+// timeField returns the time.Time field name of row, which is a pointer to
+// a struct.
+func timeField(row any, name string) (time.Time, bool) {
+	if r, ok := row.(*RowType); ok && name == "StartTime" {
+		return r.StartTime, true
+	}
+	v := reflect.ValueOf(row).Elem().FieldByName(name)
+	if !v.IsValid() {
+		return time.Time{}, false
+	}
+	t, ok := v.Interface().(time.Time)
+	return t, ok
+}
+
+// stringField returns the string field name of row, which is a pointer to
+// a struct.
+func stringField(row any, name string) string {
+	v := reflect.ValueOf(row).Elem().FieldByName(name)
+	if !v.IsValid() || v.Kind() != reflect.String {
+		return ""
+	}
+	return v.String()
+}
@@ -0,0 +1,276 @@
+package fasthttp
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"runtime/metrics"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// RuntimeSample is a row of WithRuntimeSampler, which holds values of
+// runtime/metrics over an interval.
+type RuntimeSample struct {
+	// StartTime is the start of the interval, so that samples are joined
+	// with requests by time.
+	StartTime time.Time     `parquet:",delta"`
+	Interval  time.Duration `parquet:",delta"`
+	Instance  string        `parquet:",dict"`
+	// Goroutines, HeapInUse and HeapGoal are values at the end of the
+	// interval.
+	Goroutines int64 `parquet:",delta"`
+	HeapInUse  int64 `parquet:",delta"`
+	HeapGoal   int64 `parquet:",delta"`
+	// GCCycles is the number of GC cycles completed in the interval.
+	GCCycles int64 `parquet:",delta"`
+	// GCPauses is the number of stop-the-world pauses for GC. GCPauseTotal
+	// and GCPauseMax are estimated from buckets of the histogram.
+	GCPauses     int64         `parquet:",delta"`
+	GCPauseTotal time.Duration `parquet:",delta"`
+	GCPauseMax   time.Duration `parquet:",delta"`
+	// SchedLatency columns are percentiles of the time goroutines waited
+	// to run, estimated from buckets of the histogram.
+	SchedLatencyP50 time.Duration `parquet:",delta"`
+	SchedLatencyP99 time.Duration `parquet:",delta"`
+	SchedLatencyMax time.Duration `parquet:",delta"`
+	// CPU columns are estimated CPU seconds spent in the interval.
+	CPUUser     float64
+	CPUGC       float64
+	CPUScavenge float64
+	CPUIdle     float64
+	CPUTotal    float64
+}
+
+var runtimeSampleSchema = parquet.SchemaOf(new(RuntimeSample))
+
+// runtimeSampleNames are the names of runtime/metrics in RuntimeSample.
+var runtimeSampleNames = []string{
+	"/sched/goroutines:goroutines",
+	"/memory/classes/heap/objects:bytes",
+	"/gc/heap/goal:bytes",
+	"/gc/cycles/total:gc-cycles",
+	"/sched/pauses/total/gc:seconds",
+	"/sched/latencies:seconds",
+	"/cpu/classes/user:cpu-seconds",
+	"/cpu/classes/gc/total:cpu-seconds",
+	"/cpu/classes/scavenge/total:cpu-seconds",
+	"/cpu/classes/idle:cpu-seconds",
+	"/cpu/classes/total:cpu-seconds",
+}
+
+// runtimeSampleReader turns cumulative values of runtime/metrics into
+// values of intervals.
+type runtimeSampleReader struct {
+	samples []metrics.Sample
+	at      time.Time
+	prev    runtimeTotals
+}
+
+// runtimeTotals are cumulative values of runtime/metrics.
+type runtimeTotals struct {
+	gcCycles     int64
+	pauses       []uint64
+	sched        []uint64
+	cpu          [5]float64
+	pauseBuckets []float64
+	schedBuckets []float64
+}
+
+func newRuntimeSampleReader(now time.Time) *runtimeSampleReader {
+	s := &runtimeSampleReader{samples: make([]metrics.Sample, len(runtimeSampleNames))}
+	for i, name := range runtimeSampleNames {
+		s.samples[i].Name = name
+	}
+	metrics.Read(s.samples)
+	s.at, s.prev = now.Round(0), s.totals()
+	return s
+}
+
+func (s *runtimeSampleReader) totals() runtimeTotals {
+	t := runtimeTotals{gcCycles: sampleInt64(s.samples[3])}
+	t.pauses, t.pauseBuckets = sampleHistogram(s.samples[4])
+	t.sched, t.schedBuckets = sampleHistogram(s.samples[5])
+	for i := range t.cpu {
+		if v := s.samples[6+i].Value; v.Kind() == metrics.KindFloat64 {
+			t.cpu[i] = v.Float64()
+		}
+	}
+	return t
+}
+
+// sample reads runtime/metrics and returns the sample of the interval since
+// the previous call.
+func (s *runtimeSampleReader) sample(now time.Time) RuntimeSample {
+	// Intervals are of the wall clock, so that they join samples.
+	now = now.Round(0)
+	metrics.Read(s.samples)
+	cur := s.totals()
+	prev := s.prev
+	pauses := diffCounts(cur.pauses, prev.pauses)
+	sched := diffCounts(cur.sched, prev.sched)
+	var npauses uint64
+	for _, n := range pauses {
+		npauses += n
+	}
+	r := RuntimeSample{
+		StartTime:       s.at,
+		Interval:        now.Sub(s.at),
+		Goroutines:      sampleInt64(s.samples[0]),
+		HeapInUse:       sampleInt64(s.samples[1]),
+		HeapGoal:        sampleInt64(s.samples[2]),
+		GCCycles:        cur.gcCycles - prev.gcCycles,
+		GCPauses:        int64(npauses),
+		GCPauseTotal:    histogramSum(pauses, cur.pauseBuckets),
+		GCPauseMax:      histogramQuantile(pauses, cur.pauseBuckets, 1),
+		SchedLatencyP50: histogramQuantile(sched, cur.schedBuckets, 0.5),
+		SchedLatencyP99: histogramQuantile(sched, cur.schedBuckets, 0.99),
+		SchedLatencyMax: histogramQuantile(sched, cur.schedBuckets, 1),
+		CPUUser:         cur.cpu[0] - prev.cpu[0],
+		CPUGC:           cur.cpu[1] - prev.cpu[1],
+		CPUScavenge:     cur.cpu[2] - prev.cpu[2],
+		CPUIdle:         cur.cpu[3] - prev.cpu[3],
+		CPUTotal:        cur.cpu[4] - prev.cpu[4],
+	}
+	s.at, s.prev = now, cur
+	return r
+}
+
+// sampleHistogram returns a copy of the counts and the buckets of s.
+func sampleHistogram(s metrics.Sample) ([]uint64, []float64) {
+	if s.Value.Kind() != metrics.KindFloat64Histogram {
+		return nil, nil
+	}
+	h := s.Value.Float64Histogram()
+	return slices.Clone(h.Counts), h.Buckets
+}
+
+func diffCounts(cur, prev []uint64) []uint64 {
+	diff := slices.Clone(cur)
+	if len(prev) == len(cur) {
+		for i := range diff {
+			diff[i] -= prev[i]
+		}
+	}
+	return diff
+}
+
+// bucketValue returns a finite value of the bucket i, preferring its upper
+// bound.
+func bucketValue(buckets []float64, i int) float64 {
+	if hi := buckets[i+1]; !math.IsInf(hi, 0) {
+		return hi
+	}
+	if lo := buckets[i]; !math.IsInf(lo, 0) {
+		return lo
+	}
+	return 0
+}
+
+func seconds(f float64) time.Duration {
+	return time.Duration(f * float64(time.Second))
+}
+
+// histogramSum estimates the sum of values in seconds by the midpoints of
+// the buckets.
+func histogramSum(counts []uint64, buckets []float64) time.Duration {
+	var sum float64
+	for i, n := range counts {
+		if n == 0 {
+			continue
+		}
+		lo, hi := buckets[i], buckets[i+1]
+		if math.IsInf(lo, 0) || math.IsInf(hi, 0) {
+			sum += float64(n) * bucketValue(buckets, i)
+		} else {
+			sum += float64(n) * (lo + hi) / 2
+		}
+	}
+	return seconds(sum)
+}
+
+// histogramQuantile estimates the quantile q of values in seconds by the
+// upper bound of the bucket.
+func histogramQuantile(counts []uint64, buckets []float64, q float64) time.Duration {
+	var total uint64
+	for _, n := range counts {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(total)))
+	var cum uint64
+	for i, n := range counts {
+		cum += n
+		if n > 0 && cum >= rank {
+			return seconds(bucketValue(buckets, i))
+		}
+	}
+	return 0
+}
+
+// runRuntimeSampler sends a RuntimeSample to the writer goroutine on every
+// interval until the Logger is closed.
+func (pl *GenericLogger[T]) runRuntimeSampler() {
+	ticker := time.NewTicker(pl.cfg.runtimeSampleInterval)
+	defer ticker.Stop()
+	s := newRuntimeSampleReader(time.Now())
+	for {
+		select {
+		case <-pl.quitCh:
+			return
+		case now := <-ticker.C:
+			sample := s.sample(now)
+			sample.Instance = pl.cfg.labels.Instance
+			select {
+			case pl.sampleCh <- sample:
+			default:
+				pl.reportError(fmt.Errorf("Failed to add a runtime sample: %w", ErrChannelFull))
+			}
+		}
+	}
+}
+
+// runtimeSampleDir is the subdirectory of the rotate directory where Rotate
+// writes files of samples, apart from the rotated rows which have another
+// schema.
+const runtimeSampleDir = "runtime"
+
+// runtimeSampleName returns the name of the file of samples exported with
+// name, such as runtime-log.parquet for log.parquet.
+func runtimeSampleName(name string) string {
+	base := filepath.Base(name)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	return filepath.Join(filepath.Dir(name), "runtime-"+base+".parquet")
+}
+
+// exportRuntime finishes the tempfile of samples and copies it into the
+// companion file of req. tf is closed by the caller.
+func (pl *GenericLogger[T]) exportRuntime(tf *tempfile[RuntimeSample], req exportRequest) error {
+	f, w := tf.f, tf.w
+	setMetadata(w, pl.meta, tf.stats, 0)
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("Failed to close parquet writer: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("Failed to seek tempfile: %w", err)
+	}
+	out, err := req.openRuntime()
+	if err != nil {
+		return fmt.Errorf("Failed to create %s: %w", req.runtimeName, err)
+	}
+	if _, err := io.Copy(ctxWriter{ctx: req.ctx, w: out}, f); err != nil {
+		abort(out)
+		return fmt.Errorf("Failed to copy from %s to %s: %w", f.Name(), req.runtimeName, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("Failed to close %s: %w", req.runtimeName, err)
+	}
+	pl.cfg.logger.Info("Succeed to export", "filename", req.runtimeName)
+	return nil
+}
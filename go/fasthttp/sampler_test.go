@@ -0,0 +1,122 @@
+package fasthttp
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestRuntimeSampleReader(t *testing.T) {
+	start := time.Now()
+	r := newRuntimeSampleReader(start)
+	runtime.GC()
+	s := r.sample(start.Add(time.Second))
+	if !s.StartTime.Equal(start) || s.Interval != time.Second {
+		t.Errorf("got interval %v from %v, want a second from %v", s.Interval, s.StartTime, start)
+	}
+	if s.Goroutines <= 0 || s.HeapInUse <= 0 || s.HeapGoal <= 0 {
+		t.Errorf("got %+v, want goroutines and heap", s)
+	}
+	if s.GCCycles < 1 || s.GCPauses < 1 || s.GCPauseTotal <= 0 || s.GCPauseMax <= 0 {
+		t.Errorf("got %+v, want a GC", s)
+	}
+	next := r.sample(start.Add(2 * time.Second))
+	if !next.StartTime.Equal(start.Add(time.Second)) || next.Interval != time.Second {
+		t.Errorf("got %+v, want the next interval", next)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	buckets := []float64{math.Inf(-1), 0.001, 0.002, 0.004, math.Inf(1)}
+	counts := []uint64{0, 3, 0, 1}
+	if got, want := histogramSum(counts, buckets), 3*1500*time.Microsecond+4*time.Millisecond; got != want {
+		t.Errorf("got sum %v, want %v", got, want)
+	}
+	for _, tt := range []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.5, 2 * time.Millisecond},
+		{0.75, 2 * time.Millisecond},
+		{0.99, 4 * time.Millisecond},
+		{1, 4 * time.Millisecond},
+	} {
+		if got := histogramQuantile(counts, buckets, tt.q); got != tt.want {
+			t.Errorf("q=%v: got %v, want %v", tt.q, got, tt.want)
+		}
+	}
+	if got := histogramQuantile([]uint64{0, 0, 0, 0}, buckets, 1); got != 0 {
+		t.Errorf("got %v of an empty histogram, want 0", got)
+	}
+}
+
+func TestRuntimeSampler(t *testing.T) {
+	dir := t.TempDir()
+	pl := NewLogger(WithRuntimeSampler(5*time.Millisecond), WithRotateDir(dir), WithLabels(Labels{Instance: "app1"}))
+	defer pl.Close()
+
+	readSamples := func(filename string) []RuntimeSample {
+		t.Helper()
+		samples, err := parquet.ReadFile[RuntimeSample](filename)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", filename, err)
+		}
+		return samples
+	}
+	time.Sleep(50 * time.Millisecond)
+	filename := filepath.Join(dir, "access.parquet")
+	if err := pl.Export(filename); err != nil {
+		t.Fatal(err)
+	}
+	samples := readSamples(filepath.Join(dir, "runtime-access.parquet"))
+	if len(samples) == 0 {
+		t.Fatal("got no samples")
+	}
+	for i, s := range samples {
+		if s.Instance != "app1" || s.Goroutines <= 0 {
+			t.Errorf("sample %d: got %+v", i, s)
+		}
+		if i > 0 && !s.StartTime.Equal(samples[i-1].StartTime.Add(samples[i-1].Interval)) {
+			t.Errorf("sample %d: got %v, want the end of the previous sample", i, s.StartTime)
+		}
+	}
+	last := samples[len(samples)-1]
+
+	time.Sleep(50 * time.Millisecond)
+	if err := pl.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, runtimeSampleDir, "log-*.parquet"))
+	if len(matches) != 1 {
+		t.Fatalf("got %v, want a rotated file of samples", matches)
+	}
+	if samples := readSamples(matches[0]); len(samples) == 0 || samples[0].StartTime.Before(last.StartTime.Add(last.Interval)) {
+		t.Errorf("got %d samples from %v, want samples after the export", len(samples), samples)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "runtime-log-*.parquet")); len(matches) > 0 {
+		t.Errorf("got %v, want no file of samples next to the rotated rows", matches)
+	}
+	var files []retainedFile
+	pl.listRotated(dir, nil, true, &files)
+	if !slices.ContainsFunc(files, func(f retainedFile) bool { return f.path == matches[0] }) {
+		t.Errorf("%s is not subject to the retention", matches[0])
+	}
+}
+
+func TestRuntimeSamplerDisabled(t *testing.T) {
+	dir := t.TempDir()
+	pl := NewLogger()
+	defer pl.Close()
+	if err := pl.Export(filepath.Join(dir, "log.parquet")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "runtime-log.parquet")); !os.IsNotExist(err) {
+		t.Errorf("got a file of samples without WithRuntimeSampler: %v", err)
+	}
+}
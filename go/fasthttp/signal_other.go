@@ -0,0 +1,16 @@
+//go:build !unix
+
+package fasthttp
+
+import "os"
+
+// Default signals of SignalConfig. Windows has neither SIGUSR1 nor SIGHUP,
+// so only Flush is handled by default.
+var (
+	defaultExportSignal os.Signal
+	defaultRotateSignal os.Signal
+	defaultFlushSignal  os.Signal = os.Interrupt
+)
+
+// raiseSignal exits instead of raising the flush signal again.
+var raiseSignal = exitInterrupted
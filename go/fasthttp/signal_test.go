@@ -0,0 +1,43 @@
+package fasthttp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestHandleSignalFlushExit runs the flush signal as on Windows, where it
+// cannot be raised again.
+func TestHandleSignalFlushExit(t *testing.T) {
+	dir := t.TempDir()
+	code := 0
+	exit = func(c int) { code = c }
+	raise := raiseSignal
+	raiseSignal = exitInterrupted
+	defer func() {
+		exit = os.Exit
+		raiseSignal = raise
+	}()
+
+	pl := NewLogger()
+	defer pl.Close()
+	sendAndWait(pl, RowType{StartTime: time.Now()})
+	cfg := SignalConfig{
+		Path:  filepath.Join(dir, "log.parquet"),
+		Flush: os.Interrupt,
+		OnError: func(err error) {
+			t.Errorf("Failed to handle signal: %v", err)
+		},
+	}
+	stopped := false
+	if pl.handleSignal(cfg, os.Interrupt, func() { stopped = true }) {
+		t.Error("Signals are still handled after the flush signal")
+	}
+	if !stopped || code != exitCodeInterrupted {
+		t.Errorf("got stopped %v and exit code %d, want true and %d", stopped, code, exitCodeInterrupted)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "log-*.parquet")); len(files) != 1 {
+		t.Errorf("got %v, want an exported file", files)
+	}
+}
@@ -0,0 +1,96 @@
+package fasthttp
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// A Sink receives rows from the writer goroutine of a Logger. Its methods
+// are never called concurrently.
+type Sink[T any] interface {
+	// Write writes rows. rows must not be retained after Write returns.
+	Write(rows []T) error
+	// Flush writes buffered rows to the destination.
+	Flush() error
+	// Close flushes buffered rows and releases the Sink. It is called when
+	// the Logger is closed.
+	Close() error
+}
+
+// maxBatchRows is the maximum number of rows passed to Sink.Write at once.
+const maxBatchRows = 256
+
+// ParquetSink writes rows into a parquet file.
+type ParquetSink[T any] struct {
+	out io.WriteCloser
+	rowFile[T]
+}
+
+// NewParquetSink returns a Sink which writes a parquet file into out. The
+// file is completed when the Sink is closed. Options about the layout of
+// files, such as WithSortByStartTime and WithBloomFilters, are applied.
+func NewParquetSink[T any](out io.WriteCloser, opts ...Option) *ParquetSink[T] {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &ParquetSink[T]{
+		out:     out,
+		rowFile: newRowFile[T](&cfg, parquet.SchemaOf(new(T)), out),
+	}
+}
+
+// Close writes the footer of the parquet file and closes the destination.
+func (s *ParquetSink[T]) Close() error {
+	if err := s.w.Close(); err != nil {
+		s.out.Close()
+		return err
+	}
+	return s.out.Close()
+}
+
+// sinkSet holds the sinks added by AddSink.
+type sinkSet[T any] []Sink[T]
+
+// collector is implemented by sinks which export rows on behalf of the
+// Logger, so that the Logger needs no tempfile of its own.
+type collector interface {
+	collectsRows()
+}
+
+// collectorOnly reports whether the only sink is a collector.
+func (set sinkSet[T]) collectorOnly() bool {
+	if len(set) != 1 {
+		return false
+	}
+	_, ok := set[0].(collector)
+	return ok
+}
+
+// write writes rows to every sink. A failure of a sink does not affect the
+// others.
+func (set sinkSet[T]) write(rows []T, report func(error)) {
+	for _, s := range set {
+		if err := s.Write(rows); err != nil {
+			report(fmt.Errorf("Failed to write to %T: %w", s, err))
+		}
+	}
+}
+
+func (set sinkSet[T]) flush(report func(error)) {
+	for _, s := range set {
+		if err := s.Flush(); err != nil {
+			report(fmt.Errorf("Failed to flush %T: %w", s, err))
+		}
+	}
+}
+
+func (set sinkSet[T]) close(report func(error)) {
+	for _, s := range set {
+		if err := s.Close(); err != nil {
+			report(fmt.Errorf("Failed to close %T: %w", s, err))
+		}
+	}
+}
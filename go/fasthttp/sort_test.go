@@ -0,0 +1,59 @@
+package fasthttp
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestSortByStartTime(t *testing.T) {
+	pl := NewLogger(WithSortByStartTime(3), WithBloomFilters())
+	defer pl.Close()
+
+	start := time.Now()
+	for _, d := range []int{2, 0, 1, 5, 3, 4, 6} {
+		sendAndWait(pl, RowType{
+			StartTime: start.Add(time.Duration(d) * time.Second),
+			Pattern:   "/user/{id}",
+		})
+	}
+	var buf bytes.Buffer
+	if err := pl.ExportTo(context.Background(), &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open parquet: %v", err)
+	}
+	if n := len(f.RowGroups()); n != 3 {
+		t.Fatalf("got %d row groups, want 3", n)
+	}
+	startTime, _ := f.Schema().Lookup("StartTime")
+	pattern, _ := f.Schema().Lookup("Pattern")
+	for i, rg := range f.RowGroups() {
+		sorting := rg.SortingColumns()
+		if len(sorting) != 1 || sorting[0].Path()[0] != "StartTime" {
+			t.Errorf("row group %d: unexpected sorting columns %v", i, sorting)
+		}
+		rows := make([]RowType, rg.NumRows())
+		r := parquet.NewGenericRowGroupReader[RowType](rg)
+		if n, _ := r.Read(rows); n != len(rows) {
+			t.Fatalf("row group %d: read %d rows, want %d", i, n, len(rows))
+		}
+		for j := 1; j < len(rows); j++ {
+			if rows[j].StartTime.Before(rows[j-1].StartTime) {
+				t.Errorf("row group %d is not sorted", i)
+			}
+		}
+		chunks := rg.ColumnChunks()
+		if _, err := chunks[startTime.ColumnIndex].ColumnIndex(); err != nil {
+			t.Errorf("row group %d: no column index: %v", i, err)
+		}
+		if chunks[pattern.ColumnIndex].BloomFilter() == nil {
+			t.Errorf("row group %d: no bloom filter of Pattern", i)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package gin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// clickHouseStub is a stand-in of the HTTP interface of ClickHouse which
+// fails the first request.
+type clickHouseStub struct {
+	mu      sync.Mutex
+	failed  bool
+	queries []string
+	users   []string
+	bodies  [][]byte
+}
+
+func (s *clickHouseStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.failed {
+		s.failed = true
+		http.Error(w, "Code: 202. DB::Exception: Too many simultaneous queries", http.StatusServiceUnavailable)
+		return
+	}
+	s.queries = append(s.queries, r.URL.Query().Get("query"))
+	s.users = append(s.users, r.Header.Get("X-ClickHouse-User"))
+	s.bodies = append(s.bodies, body)
+}
+
+// readRowBinary decodes rows appended by appendRowBinary.
+func readRowBinary(t *testing.T, buf []byte) []RowType {
+	t.Helper()
+	r := bufio.NewReader(bytes.NewReader(buf))
+	readInt64 := func() int64 {
+		var n int64
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			t.Fatalf("Failed to read RowBinary: %v", err)
+		}
+		return n
+	}
+	readUvarint := func() uint64 {
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			t.Fatalf("Failed to read RowBinary: %v", err)
+		}
+		return n
+	}
+	readString := func() string {
+		b := make([]byte, readUvarint())
+		if _, err := io.ReadFull(r, b); err != nil {
+			t.Fatalf("Failed to read RowBinary: %v", err)
+		}
+		return string(b)
+	}
+	readHeaders := func() map[string][]string {
+		h := make(map[string][]string)
+		for n := readUvarint(); n > 0; n-- {
+			k := readString()
+			for m := readUvarint(); m > 0; m-- {
+				h[k] = append(h[k], readString())
+			}
+		}
+		return h
+	}
+	var rows []RowType
+	for {
+		if _, err := r.Peek(1); err == io.EOF {
+			return rows
+		}
+		var row RowType
+		row.StartTime = time.Unix(0, readInt64())
+		row.Latency = time.Duration(readInt64())
+		row.Protocol = readString()
+		row.Scheme = readString()
+		row.RemoteAddr = readString()
+		row.Host = readString()
+		row.Method = readString()
+		row.URL = readString()
+		row.Pattern = readString()
+		row.Status = int(readInt64())
+		row.RequestSize = readInt64()
+		row.ResponseSize = readInt64()
+		row.RequestHeaders = readHeaders()
+		row.ResponseHeaders = readHeaders()
+		if null, _ := r.ReadByte(); null == 0 {
+			s := readString()
+			row.Error = &s
+		}
+		row.Instance = readString()
+		row.Service = readString()
+		row.Version = readString()
+		row.Environment = readString()
+		for _, p := range []**int64{&row.Goroutines, &row.HeapInUse, &row.GCCycleStart, &row.GCCycleEnd} {
+			if null, _ := r.ReadByte(); null == 0 {
+				n := readInt64()
+				*p = &n
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestClickHouseSink(t *testing.T) {
+	errStr := "boom"
+	start := time.Now()
+	goroutines := int64(12)
+	rows := []RowType{
+		{StartTime: start, Latency: time.Millisecond, Method: "GET", Status: 200, RequestHeaders: map[string][]string{"Accept": {"a", "b"}}, Instance: "app1", Goroutines: &goroutines},
+		{StartTime: start.Add(time.Second), Method: "POST", Status: 500, Error: &errStr},
+	}
+	for _, format := range []ClickHouseFormat{ClickHouseParquet, ClickHouseRowBinary} {
+		stub := &clickHouseStub{}
+		srv := httptest.NewServer(stub)
+		s := NewClickHouseSink(ClickHouseConfig{
+			Endpoint: srv.URL + "/",
+			Table:    "db.access",
+			Format:   format,
+			User:     "writer",
+			BatchConfig: BatchConfig{RetryConfig: RetryConfig{
+				RetryInterval: time.Millisecond,
+				OnError: func(err error) {
+					t.Errorf("Failed to insert: %v", err)
+				},
+			}},
+		})
+		if err := s.Write(rows); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+		srv.Close()
+
+		if len(stub.bodies) != 1 {
+			t.Fatalf("got %d inserts, want 1", len(stub.bodies))
+		}
+		wantFormat := map[ClickHouseFormat]string{ClickHouseParquet: "FORMAT Parquet", ClickHouseRowBinary: "FORMAT RowBinary"}[format]
+		if q := stub.queries[0]; !strings.HasPrefix(q, "INSERT INTO db.access (StartTime, Latency,") || !strings.HasSuffix(q, wantFormat) {
+			t.Errorf("unexpected query: %s", q)
+		}
+		if stub.users[0] != "writer" {
+			t.Errorf("got user %q, want writer", stub.users[0])
+		}
+		var got []RowType
+		if format == ClickHouseRowBinary {
+			got = readRowBinary(t, stub.bodies[0])
+		} else {
+			var err error
+			got, err = parquet.Read[RowType](bytes.NewReader(stub.bodies[0]), int64(len(stub.bodies[0])))
+			if err != nil {
+				t.Fatalf("Failed to read parquet: %v", err)
+			}
+		}
+		if len(got) != 2 {
+			t.Fatalf("got %d rows, want 2", len(got))
+		}
+		if !got[0].StartTime.Equal(start) || got[0].Latency != time.Millisecond || got[0].Method != "GET" ||
+			len(got[0].RequestHeaders["Accept"]) != 2 || got[0].Instance != "app1" || got[0].Error != nil ||
+			got[0].Goroutines == nil || *got[0].Goroutines != 12 || got[0].GCCycleEnd != nil {
+			t.Errorf("got %+v", got[0])
+		}
+		if got[1].Status != 500 || got[1].Error == nil || *got[1].Error != "boom" {
+			t.Errorf("got %+v", got[1])
+		}
+	}
+}
+
+func TestClickHouseDDL(t *testing.T) {
+	buf, err := os.ReadFile("../../sql/clickhouse/table.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(buf), ClickHouseDDL("logs")+";\n") {
+		t.Error("sql/clickhouse/table.sql is not generated by ClickHouseDDL")
+	}
+	fields := parquet.SchemaOf(new(RowType)).Fields()
+	if len(fields) != len(clickHouseColumns) {
+		t.Fatalf("got %d columns, want %d", len(clickHouseColumns), len(fields))
+	}
+	for i, f := range fields {
+		if clickHouseColumns[i].name != f.Name() {
+			t.Errorf("column %d: got %s, want %s", i, clickHouseColumns[i].name, f.Name())
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package gin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+type bufferCloser struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *bufferCloser) Close() error {
+	b.closed = true
+	return nil
+}
+
+func sendAndWait[T any](pl *GenericLogger[T], rows ...T) {
+	for _, row := range rows {
+		pl.send(row)
+	}
+	for len(pl.ch) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestExportTo(t *testing.T) {
+	pl := NewLogger()
+	defer pl.Close()
+
+	sendAndWait(pl, RowType{Method: "GET"}, RowType{Method: "POST"})
+	var buf bytes.Buffer
+	if err := pl.ExportTo(context.Background(), &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	rows, err := parquet.Read[RowType](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read parquet: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := pl.ExportTo(ctx, io.Discard); !errors.Is(err, context.Canceled) {
+		t.Errorf("ExportTo with canceled context: got %v, want context.Canceled", err)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken")
+}
+
+func TestExportFailureKeepsRows(t *testing.T) {
+	pl := NewLogger()
+	defer pl.Close()
+
+	sendAndWait(pl, RowType{Method: "GET"}, RowType{Method: "POST"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := pl.ExportTo(ctx, io.Discard); err == nil {
+		t.Fatal("ExportTo with canceled context succeeded")
+	}
+	if err := pl.ExportTo(context.Background(), failingWriter{}); err == nil {
+		t.Fatal("ExportTo to failing writer succeeded")
+	}
+	sendAndWait(pl, RowType{Method: "PUT"})
+	var buf bytes.Buffer
+	if err := pl.ExportTo(context.Background(), &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	if n := countRows(t, buf.Bytes()); n != 3 {
+		t.Errorf("got %d rows, want 3", n)
+	}
+}
+
+func TestWriterFactory(t *testing.T) {
+	var names []string
+	out := &bufferCloser{}
+	pl := NewLogger(WithWriterFactory(func(ctx context.Context, name string) (io.WriteCloser, error) {
+		names = append(names, name)
+		return out, nil
+	}))
+	defer pl.Close()
+
+	sendAndWait(pl, RowType{Method: "GET"})
+	if err := pl.Rotate(); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	if len(names) != 1 || !strings.HasPrefix(names[0], "log-") || !strings.HasSuffix(names[0], ".parquet") {
+		t.Errorf("unexpected names: %v", names)
+	}
+	if !out.closed {
+		t.Errorf("writer is not closed")
+	}
+	rows, err := parquet.Read[RowType](bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read parquet: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("got %d rows, want 1", len(rows))
+	}
+}
@@ -2,143 +2,58 @@
 package gin
 
 import (
-	"fmt"
-	"io"
-	"log"
-	"os"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/parquet-go/parquet-go"
-	"github.com/parquet-go/parquet-go/format"
 )
 
-// RowType contains extracted values from logger.
-type RowType struct {
-	StartTime       time.Time           `parquet:",delta"`
-	Latency         time.Duration       `parquet:",delta"`
-	Protocol        string              `parquet:",dict"`
-	RemoteAddr      string              `parquet:",dict"`
-	Host            string              `parquet:",dict"`
-	Method          string              `parquet:",dict"`
-	URL             string              `parquet:",dict"`
-	Pattern         string              `parquet:",dict"`
-	Status          int                 `parquet:",dict"`
-	RequestSize     int64               `parquet:",delta"`
-	ResponseSize    int64               `parquet:",delta"`
-	RequestHeaders  map[string][]string `parquet:","`
-	ResponseHeaders map[string][]string `parquet:","`
-	Error           *string             `parquet:","`
-}
-
-// A Logger defines parameters for logging.
-type Logger struct {
-	ch       chan RowType
-	exportCh chan string
-	doneCh   chan error
-}
+// adapterName is recorded in the metadata of exported files.
+const adapterName = "gin"
 
-// NewLogger returns a new Logger.
-func NewLogger() *Logger {
-	pl := &Logger{
-		ch:     make(chan RowType, 64),
-		doneCh: make(chan error),
-	}
-	go func() {
-		pl.doneCh <- pl.run()
-	}()
-	return pl
-}
-
-func (pl *Logger) run() error {
-	if pl.ch == nil {
-		log.Fatal("No channel is defined in Logger. Please use NewLogger")
-	}
-	f, err := os.CreateTemp("", ".parquet-logger-*.parquet")
-	if err != nil {
-		log.Fatalf("Failed to create tempfile: %v", err)
-	}
-	os.Remove(f.Name())
-	w := parquet.NewGenericWriter[RowType](f, parquet.Compression(parquet.LookupCompressionCodec(format.Snappy)))
-
-	pl.exportCh = make(chan string)
-	var filename string
-
-L:
-	for {
-		select {
-		case row, ok := <-pl.ch:
-			if !ok {
-				break L
-			}
-			if _, err := w.Write([]RowType{row}); err != nil {
-				log.Printf("Failed to write parquet: %v", err)
-			}
-		case filename = <-pl.exportCh:
-			break L
-		}
-	}
-	if err := w.Close(); err != nil {
-		return fmt.Errorf("Failed to close parquet writer: %w", err)
-	}
-	if filename != "" {
-		out, err := os.Create(filename)
-		if err != nil {
-			return fmt.Errorf("Failed to create %s: %w", filename, err)
-		}
-		if err := f.Sync(); err != nil {
-			return fmt.Errorf("Failed to sync tempfile: %w", err)
-		}
-		if _, err := f.Seek(0, io.SeekStart); err != nil {
-			return fmt.Errorf("Failed to seek tempfile: %w", err)
-		}
-		if _, err := io.Copy(out, f); err != nil {
-			return fmt.Errorf("Failed to copy from %s to %s: %v", f.Name(), out.Name(), err)
-		}
-		if err := out.Close(); err != nil {
-			return fmt.Errorf("Failed to close %s: %w", out.Name(), err)
-		}
-		log.Printf("Succeed to export %s", filename)
-	}
-	if err := f.Close(); err != nil {
-		return fmt.Errorf("Failed to close tempfile: %w", err)
-
-	}
-	return nil
-}
-
-// Export exports parquet file.
-func (pl *Logger) Export(filename string) error {
-	pl.exportCh <- filename
-	go func() {
-		pl.doneCh <- pl.run()
-	}()
-	return <-pl.doneCh
-}
-
-func (pl *Logger) send(row RowType) {
-	select {
-	case pl.ch <- row:
-	default:
-		log.Printf("Failed to add to channel: Capacity limit reached. Consider increasing the channel size.")
-	}
+// RequestInfo contains values of a request which are passed to an extractor.
+type RequestInfo struct {
+	StartTime time.Time
+	Latency   time.Duration
+	Protocol  string
+	// Scheme is http or https.
+	Scheme          string
+	RemoteAddr      string
+	Host            string
+	Method          string
+	URL             string
+	Pattern         string
+	Status          int
+	RequestSize     int64
+	ResponseSize    int64
+	RequestHeaders  map[string][]string
+	ResponseHeaders map[string][]string
+	Error           *string
+	Labels          Labels
+	// Runtime is set by WithRuntimeStats.
+	Runtime *RuntimeStats
+	// Context is the context of the served request.
+	Context *gin.Context
 }
 
 // Middleware returns logger middleware.
-func (pl *Logger) Middleware() gin.HandlerFunc {
+func (pl *GenericLogger[T]) Middleware() gin.HandlerFunc {
 	now := time.Now
 	return func(c *gin.Context) {
 		// Before
 		start := now()
+		rs := pl.runtimeStart()
 		// Next
 		c.Next()
 
 		// After
 		latency := now().Sub(start)
-		row := RowType{
+		pl.runtimeEnd(rs)
+		info := RequestInfo{
 			StartTime:       start,
 			Latency:         latency,
 			Protocol:        c.Request.Proto,
+			Scheme:          scheme(c.Request),
 			RemoteAddr:      c.Request.RemoteAddr,
 			Host:            c.Request.Host,
 			Method:          c.Request.Method,
@@ -149,10 +64,20 @@ func (pl *Logger) Middleware() gin.HandlerFunc {
 			ResponseSize:    int64(c.Writer.Size()),
 			RequestHeaders:  c.Request.Header,
 			ResponseHeaders: c.Writer.Header(),
+			Runtime:         rs,
+			Context:         c,
 		}
 		if errStr := c.Errors.String(); errStr != "" {
-			row.Error = &errStr
+			info.Error = &errStr
 		}
-		pl.send(row)
+		pl.log(info)
+	}
+}
+
+// scheme returns the scheme of r which is served directly.
+func scheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
 	}
+	return "http"
 }
@@ -0,0 +1,48 @@
+package gin
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestMetadata(t *testing.T) {
+	pl := NewLogger(WithMetadata(map[string]string{
+		"team":    "web",
+		"adapter": "overridden",
+	}))
+	defer pl.Close()
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	sendAndWait(pl, RowType{StartTime: start.Add(time.Second)}, RowType{StartTime: start})
+	pl.dropped.Add(3)
+
+	var buf bytes.Buffer
+	if err := pl.ExportTo(context.Background(), &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open parquet: %v", err)
+	}
+	for k, want := range map[string]string{
+		"team":             "web",
+		"adapter":          adapterName,
+		"first_start_time": "2024-01-02T03:04:05Z",
+		"last_start_time":  "2024-01-02T03:04:06Z",
+		"row_count":        "2",
+		"dropped_count":    "3",
+	} {
+		if got, _ := f.Lookup(k); got != want {
+			t.Errorf("%s: got %q, want %q", k, got, want)
+		}
+	}
+	for _, k := range []string{"hostname", "pid", "go_version", "module_version", "config"} {
+		if _, ok := f.Lookup(k); !ok {
+			t.Errorf("%s is missing", k)
+		}
+	}
+}
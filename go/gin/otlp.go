@@ -0,0 +1,385 @@
+package gin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// OTLPConfig defines where and how OTLPSink exports logs.
+type OTLPConfig struct {
+	// Endpoint is the URL of the OTLP/HTTP logs endpoint. The default is
+	// http://localhost:4318/v1/logs.
+	Endpoint string
+	// JSON sends requests in JSON instead of protobuf.
+	JSON bool
+	// Headers are added to every request, e.g. for authentication.
+	Headers map[string]string
+	// BatchConfig defines batching and retries. The default MaxBatchRows
+	// is 512.
+	BatchConfig
+}
+
+// OTLPSink exports rows as OpenTelemetry log records over OTLP/HTTP.
+// Requests are sent in background, so that a slow collector does not block
+// the Logger.
+type OTLPSink struct {
+	cfg     OTLPConfig
+	version string
+	q       *batchQueue[otlpEntry]
+}
+
+var _ Sink[RowType] = (*OTLPSink)(nil)
+
+// NewOTLPSink returns a Sink which exports rows to an OTLP/HTTP endpoint.
+func NewOTLPSink(cfg OTLPConfig) *OTLPSink {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "http://localhost:4318/v1/logs"
+	}
+	cfg.setDefaults(512)
+	s := &OTLPSink{cfg: cfg, version: moduleVersion()}
+	s.q = newBatchQueue(&s.cfg.BatchConfig, s.send)
+	return s
+}
+
+// Write implements Sink.
+func (s *OTLPSink) Write(rows []RowType) error {
+	observed := uint64(time.Now().UnixNano())
+	for i := range rows {
+		err := s.q.add(otlpEntry{
+			labels: labelsOf(&rows[i]),
+			record: newOTLPLogRecord(&rows[i], observed),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush queues the pending rows to be sent.
+func (s *OTLPSink) Flush() error {
+	return s.q.flush()
+}
+
+// Close sends the pending rows and waits for queued requests.
+func (s *OTLPSink) Close() error {
+	return s.q.close()
+}
+
+func (s *OTLPSink) send(batch []otlpEntry) error {
+	req := newOTLPRequest(batch, s.version)
+	var body []byte
+	contentType := "application/x-protobuf"
+	if s.cfg.JSON {
+		var err error
+		if body, err = json.Marshal(req); err != nil {
+			return err
+		}
+		contentType = "application/json"
+	} else {
+		body = req.appendProto(nil)
+	}
+	err := s.cfg.send(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, s.cfg.Endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", contentType)
+		for k, v := range s.cfg.Headers {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to export %d logs to %s: %w", len(batch), s.cfg.Endpoint, err)
+	}
+	return nil
+}
+
+func labelsOf(row *RowType) Labels {
+	return Labels{
+		Instance:    row.Instance,
+		Service:     row.Service,
+		Version:     row.Version,
+		Environment: row.Environment,
+	}
+}
+
+// otlpEntry is a log record and the labels of its resource.
+type otlpEntry struct {
+	labels Labels
+	record otlpLogRecord
+}
+
+// The types below are the subset of ExportLogsServiceRequest of
+// opentelemetry-proto which OTLPSink sends. Field names follow the JSON
+// encoding of OTLP and field numbers the protobuf encoding.
+
+type otlpRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         uint64         `json:"timeUnixNano,string"`
+	ObservedTimeUnixNano uint64         `json:"observedTimeUnixNano,string"`
+	SeverityNumber       int32          `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+// otlpAnyValue holds one of a string, an int or a double.
+type otlpAnyValue struct {
+	str    *string
+	int    *int64
+	double *float64
+}
+
+func stringValue(s string) otlpAnyValue  { return otlpAnyValue{str: &s} }
+func intValue(n int64) otlpAnyValue      { return otlpAnyValue{int: &n} }
+func doubleValue(f float64) otlpAnyValue { return otlpAnyValue{double: &f} }
+
+// MarshalJSON encodes v in the JSON encoding of OTLP.
+func (v otlpAnyValue) MarshalJSON() ([]byte, error) {
+	switch {
+	case v.str != nil:
+		return json.Marshal(map[string]string{"stringValue": *v.str})
+	case v.int != nil:
+		return json.Marshal(map[string]string{"intValue": strconv.FormatInt(*v.int, 10)})
+	case v.double != nil:
+		return json.Marshal(map[string]float64{"doubleValue": *v.double})
+	}
+	return []byte("{}"), nil
+}
+
+// Severity numbers of the OpenTelemetry log data model.
+const (
+	severityInfo  = 9
+	severityWarn  = 13
+	severityError = 17
+)
+
+func newOTLPLogRecord(row *RowType, observed uint64) otlpLogRecord {
+	rec := otlpLogRecord{
+		TimeUnixNano:         uint64(row.StartTime.UnixNano()),
+		ObservedTimeUnixNano: observed,
+		SeverityNumber:       severityInfo,
+		SeverityText:         "INFO",
+		Body:                 stringValue(fmt.Sprintf("%s %s %d", row.Method, row.URL, row.Status)),
+	}
+	switch {
+	case row.Status >= 500:
+		rec.SeverityNumber, rec.SeverityText = severityError, "ERROR"
+	case row.Status >= 400:
+		rec.SeverityNumber, rec.SeverityText = severityWarn, "WARN"
+	}
+	attr := func(key string, value otlpAnyValue) {
+		rec.Attributes = append(rec.Attributes, otlpKeyValue{Key: key, Value: value})
+	}
+	attr("http.request.method", stringValue(row.Method))
+	if row.Pattern != "" {
+		attr("http.route", stringValue(row.Pattern))
+	}
+	attr("http.response.status_code", intValue(int64(row.Status)))
+	full, path, query := otlpURL(row)
+	if full != "" {
+		attr("url.full", stringValue(full))
+	}
+	attr("url.path", stringValue(path))
+	if query != "" {
+		attr("url.query", stringValue(query))
+	}
+	if row.Scheme != "" {
+		attr("url.scheme", stringValue(row.Scheme))
+	}
+	if row.Host != "" {
+		attr("server.address", stringValue(row.Host))
+	}
+	if host, port, err := net.SplitHostPort(row.RemoteAddr); err == nil {
+		attr("client.address", stringValue(host))
+		if n, err := strconv.Atoi(port); err == nil {
+			attr("client.port", intValue(int64(n)))
+		}
+	} else if row.RemoteAddr != "" {
+		attr("client.address", stringValue(row.RemoteAddr))
+	}
+	if version, ok := strings.CutPrefix(row.Protocol, "HTTP/"); ok {
+		attr("network.protocol.version", stringValue(version))
+	}
+	attr("http.request.body.size", intValue(row.RequestSize))
+	attr("http.response.body.size", intValue(row.ResponseSize))
+	// The latency has no attribute in the semantic conventions, where
+	// http.server.request.duration is a metric.
+	attr("parquetlogger.duration", doubleValue(row.Latency.Seconds()))
+	if row.Error != nil {
+		attr("exception.message", stringValue(*row.Error))
+	}
+	return rec
+}
+
+// otlpURL returns url.full, url.path and url.query of row. URL is the
+// request target, or an absolute URL for fasthttp. url.full is empty if the
+// scheme or the host is unknown.
+func otlpURL(row *RowType) (full, path, query string) {
+	u, err := url.Parse(row.URL)
+	if err != nil {
+		path, query, _ = strings.Cut(row.URL, "?")
+		return "", path, query
+	}
+	if !u.IsAbs() && row.Scheme != "" && row.Host != "" {
+		u.Scheme, u.Host = row.Scheme, row.Host
+	}
+	if u.IsAbs() {
+		full = u.String()
+	}
+	return full, u.EscapedPath(), u.RawQuery
+}
+
+func newOTLPRequest(batch []otlpEntry, version string) *otlpRequest {
+	req := &otlpRequest{}
+	index := make(map[Labels]int)
+	for _, e := range batch {
+		i, ok := index[e.labels]
+		if !ok {
+			i = len(req.ResourceLogs)
+			index[e.labels] = i
+			req.ResourceLogs = append(req.ResourceLogs, otlpResourceLogs{
+				Resource: otlpResource{Attributes: resourceAttributes(e.labels)},
+				ScopeLogs: []otlpScopeLogs{{
+					Scope: otlpScope{Name: modulePath, Version: version},
+				}},
+			})
+		}
+		sl := &req.ResourceLogs[i].ScopeLogs[0]
+		sl.LogRecords = append(sl.LogRecords, e.record)
+	}
+	return req
+}
+
+func resourceAttributes(labels Labels) []otlpKeyValue {
+	var attrs []otlpKeyValue
+	for _, kv := range []struct{ key, value string }{
+		{"service.name", labels.Service},
+		{"service.version", labels.Version},
+		{"service.instance.id", labels.Instance},
+		{"deployment.environment.name", labels.Environment},
+	} {
+		if kv.value != "" {
+			attrs = append(attrs, otlpKeyValue{Key: kv.key, Value: stringValue(kv.value)})
+		}
+	}
+	return attrs
+}
+
+// appendMessage appends a length-delimited field holding the message
+// appended by fn.
+func appendMessage(b []byte, num protowire.Number, fn func([]byte) []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, fn(nil))
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func (req *otlpRequest) appendProto(b []byte) []byte {
+	for i := range req.ResourceLogs {
+		b = appendMessage(b, 1, req.ResourceLogs[i].appendProto)
+	}
+	return b
+}
+
+func (rl *otlpResourceLogs) appendProto(b []byte) []byte {
+	b = appendMessage(b, 1, func(b []byte) []byte {
+		return appendAttributes(b, 1, rl.Resource.Attributes)
+	})
+	for i := range rl.ScopeLogs {
+		b = appendMessage(b, 2, rl.ScopeLogs[i].appendProto)
+	}
+	return b
+}
+
+func (sl *otlpScopeLogs) appendProto(b []byte) []byte {
+	b = appendMessage(b, 1, func(b []byte) []byte {
+		b = appendString(b, 1, sl.Scope.Name)
+		if sl.Scope.Version != "" {
+			b = appendString(b, 2, sl.Scope.Version)
+		}
+		return b
+	})
+	for i := range sl.LogRecords {
+		b = appendMessage(b, 2, sl.LogRecords[i].appendProto)
+	}
+	return b
+}
+
+func (rec *otlpLogRecord) appendProto(b []byte) []byte {
+	b = protowire.AppendTag(b, 1, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, rec.TimeUnixNano)
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(rec.SeverityNumber))
+	b = appendString(b, 3, rec.SeverityText)
+	b = appendMessage(b, 5, rec.Body.appendProto)
+	b = appendAttributes(b, 6, rec.Attributes)
+	b = protowire.AppendTag(b, 11, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, rec.ObservedTimeUnixNano)
+}
+
+func appendAttributes(b []byte, num protowire.Number, attrs []otlpKeyValue) []byte {
+	for _, kv := range attrs {
+		b = appendMessage(b, num, func(b []byte) []byte {
+			b = appendString(b, 1, kv.Key)
+			return appendMessage(b, 2, kv.Value.appendProto)
+		})
+	}
+	return b
+}
+
+func (v otlpAnyValue) appendProto(b []byte) []byte {
+	switch {
+	case v.str != nil:
+		b = appendString(b, 1, *v.str)
+	case v.int != nil:
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(*v.int))
+	case v.double != nil:
+		b = protowire.AppendTag(b, 4, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(*v.double))
+	}
+	return b
+}
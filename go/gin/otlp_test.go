@@ -0,0 +1,285 @@
+package gin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// otlpStub is an OTLP/HTTP receiver which fails the first requests.
+type otlpStub struct {
+	mu       sync.Mutex
+	failures int
+	status   int
+	requests int
+	bodies   [][]byte
+	types    []string
+}
+
+func (s *otlpStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if s.failures > 0 {
+		s.failures--
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(s.status)
+		return
+	}
+	s.bodies = append(s.bodies, body)
+	s.types = append(s.types, r.Header.Get("Content-Type"))
+}
+
+// protoField is a field of a protobuf message.
+type protoField struct {
+	num    protowire.Number
+	varint uint64
+	bytes  []byte
+}
+
+func parseProto(t *testing.T, b []byte) []protoField {
+	t.Helper()
+	var fields []protoField
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("Failed to parse protobuf: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		f := protoField{num: num}
+		switch typ {
+		case protowire.VarintType:
+			f.varint, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			f.varint, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			t.Fatalf("Failed to parse protobuf: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// subMessages returns the messages of field num in b.
+func subMessages(t *testing.T, b []byte, num protowire.Number) [][]byte {
+	var msgs [][]byte
+	for _, f := range parseProto(t, b) {
+		if f.num == num {
+			msgs = append(msgs, f.bytes)
+		}
+	}
+	return msgs
+}
+
+// protoAttributes returns string and int attributes of KeyValue messages.
+func protoAttributes(t *testing.T, kvs [][]byte) map[string]string {
+	attrs := make(map[string]string)
+	for _, kv := range kvs {
+		key := string(subMessages(t, kv, 1)[0])
+		for _, f := range parseProto(t, subMessages(t, kv, 2)[0]) {
+			switch f.num {
+			case 1:
+				attrs[key] = string(f.bytes)
+			case 3:
+				attrs[key] = strconv.FormatInt(int64(f.varint), 10)
+			}
+		}
+	}
+	return attrs
+}
+
+// decodeOTLP returns attributes of the resource and the log records.
+func decodeOTLP(t *testing.T, body []byte, isJSON bool) (map[string]string, []map[string]string) {
+	t.Helper()
+	var resource map[string]string
+	var records []map[string]string
+	if !isJSON {
+		for _, rl := range subMessages(t, body, 1) {
+			resource = protoAttributes(t, subMessages(t, subMessages(t, rl, 1)[0], 1))
+			for _, sl := range subMessages(t, rl, 2) {
+				for _, rec := range subMessages(t, sl, 2) {
+					records = append(records, protoAttributes(t, subMessages(t, rec, 6)))
+				}
+			}
+		}
+		return resource, records
+	}
+	type kv struct {
+		Key   string
+		Value map[string]any
+	}
+	var req struct {
+		ResourceLogs []struct {
+			Resource  struct{ Attributes []kv }
+			ScopeLogs []struct {
+				LogRecords []struct{ Attributes []kv }
+			}
+		}
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	attrs := func(kvs []kv) map[string]string {
+		m := make(map[string]string)
+		for _, kv := range kvs {
+			for _, v := range kv.Value {
+				if s, ok := v.(string); ok {
+					m[kv.Key] = s
+				}
+			}
+		}
+		return m
+	}
+	for _, rl := range req.ResourceLogs {
+		resource = attrs(rl.Resource.Attributes)
+		for _, sl := range rl.ScopeLogs {
+			for _, rec := range sl.LogRecords {
+				records = append(records, attrs(rec.Attributes))
+			}
+		}
+	}
+	return resource, records
+}
+
+func TestOTLPSink(t *testing.T) {
+	for _, isJSON := range []bool{false, true} {
+		stub := &otlpStub{failures: 1, status: http.StatusServiceUnavailable}
+		srv := httptest.NewServer(stub)
+		s := NewOTLPSink(OTLPConfig{
+			Endpoint: srv.URL + "/v1/logs",
+			JSON:     isJSON,
+			BatchConfig: BatchConfig{
+				MaxBatchRows: 2,
+				RetryConfig: RetryConfig{
+					RetryInterval: time.Millisecond,
+					OnError: func(err error) {
+						t.Errorf("Failed to export: %v", err)
+					},
+				},
+			},
+		})
+		rows := []RowType{
+			{StartTime: time.Now(), Method: "GET", Scheme: "https", Host: "example.com", URL: "/user/1?tab=posts", Pattern: "/user/{id}", Status: 200, RemoteAddr: "192.0.2.1:1234", Service: "api"},
+			{StartTime: time.Now(), Method: "POST", URL: "/user", Pattern: "/user", Status: 500, Service: "api"},
+			{StartTime: time.Now(), Method: "GET", URL: "/", Status: 404, Service: "api"},
+		}
+		if err := s.Write(rows); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+		srv.Close()
+
+		if stub.requests != 3 || len(stub.bodies) != 2 {
+			t.Fatalf("got %d requests and %d bodies, want 3 and 2", stub.requests, len(stub.bodies))
+		}
+		wantType := "application/x-protobuf"
+		if isJSON {
+			wantType = "application/json"
+		}
+		if stub.types[0] != wantType {
+			t.Errorf("got Content-Type %s, want %s", stub.types[0], wantType)
+		}
+		resource, records := decodeOTLP(t, stub.bodies[0], isJSON)
+		if resource["service.name"] != "api" {
+			t.Errorf("got resource %v, want service.name=api", resource)
+		}
+		if len(records) != 2 {
+			t.Fatalf("got %d records, want 2", len(records))
+		}
+		want := map[string]string{
+			"http.request.method":       "GET",
+			"http.route":                "/user/{id}",
+			"http.response.status_code": "200",
+			"url.full":                  "https://example.com/user/1?tab=posts",
+			"url.scheme":                "https",
+			"url.path":                  "/user/1",
+			"url.query":                 "tab=posts",
+			"client.address":            "192.0.2.1",
+			"client.port":               "1234",
+		}
+		for k, v := range want {
+			if got := records[0][k]; got != v {
+				t.Errorf("JSON %v: got %s=%q, want %q", isJSON, k, got, v)
+			}
+		}
+		for _, k := range []string{"url.full", "url.query"} {
+			if v, ok := records[1][k]; ok {
+				t.Errorf("JSON %v: got %s=%q, want none", isJSON, k, v)
+			}
+		}
+	}
+}
+
+func TestOTLPSinkPermanentError(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusNotImplemented} {
+		stub := &otlpStub{failures: 10, status: status}
+		srv := httptest.NewServer(stub)
+		var got error
+		s := NewOTLPSink(OTLPConfig{
+			Endpoint: srv.URL,
+			BatchConfig: BatchConfig{RetryConfig: RetryConfig{
+				RetryInterval: time.Millisecond,
+				OnError: func(err error) {
+					got = err
+				},
+			}},
+		})
+		s.Write([]RowType{{Method: "GET"}})
+		s.Close()
+		srv.Close()
+		if got == nil || stub.requests != 1 {
+			t.Errorf("%d: got %v after %d requests, want an error after 1 request", status, got, stub.requests)
+		}
+	}
+}
+
+func TestOTLPSinkQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-block
+	}))
+	defer srv.Close()
+	s := NewOTLPSink(OTLPConfig{Endpoint: srv.URL, BatchConfig: BatchConfig{MaxBatchRows: 1, MaxQueuedBatches: 1}})
+	var err error
+	for i := 0; i < 3 && err == nil; i++ {
+		err = s.Write([]RowType{{Method: "GET"}})
+	}
+	close(block)
+	s.Close()
+	if err == nil {
+		t.Error("Write did not fail when the queue is full")
+	}
+}
+
+func TestOTLPURL(t *testing.T) {
+	for _, tt := range []struct {
+		row               RowType
+		full, path, query string
+	}{
+		{RowType{Scheme: "http", Host: "a:8080", URL: "/x%2Fy?q=1"}, "http://a:8080/x%2Fy?q=1", "/x%2Fy", "q=1"},
+		{RowType{URL: "/x"}, "", "/x", ""},
+		// fasthttp logs the absolute URL.
+		{RowType{Scheme: "https", Host: "a", URL: "https://a/x?q=1"}, "https://a/x?q=1", "/x", "q=1"},
+	} {
+		full, path, query := otlpURL(&tt.row)
+		if full != tt.full || path != tt.path || query != tt.query {
+			t.Errorf("%s: got %q %q %q, want %q %q %q", tt.row.URL, full, path, query, tt.full, tt.path, tt.query)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package gin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// A PartitionKey defines a directory level of the hive layout.
+type PartitionKey struct {
+	Name string
+	// Value returns the value of row, which is a pointer to a row such as
+	// *RowType.
+	Value func(row any) string
+}
+
+// PartitionByDate partitions rows by the UTC date of StartTime as dt=2006-01-02.
+func PartitionByDate() PartitionKey {
+	return PartitionKey{
+		Name: "dt",
+		Value: func(row any) string {
+			t, _ := timeField(row, "StartTime")
+			return t.UTC().Format("2006-01-02")
+		},
+	}
+}
+
+// PartitionByHour partitions rows by the UTC hour of StartTime as hour=15.
+func PartitionByHour() PartitionKey {
+	return PartitionKey{
+		Name: "hour",
+		Value: func(row any) string {
+			t, _ := timeField(row, "StartTime")
+			return t.UTC().Format("15")
+		},
+	}
+}
+
+// PartitionByHost partitions rows by Host as host=example.com.
+func PartitionByHost() PartitionKey {
+	return PartitionKey{
+		Name: "host",
+		Value: func(row any) string {
+			return stringField(row, "Host")
+		},
+	}
+}
+
+// PartitionByLabel partitions rows by a static label as name=value.
+func PartitionByLabel(name, value string) PartitionKey {
+	return PartitionKey{
+		Name: name,
+		Value: func(any) string {
+			return value
+		},
+	}
+}
+
+// maxPartitions is the maximum number of partitions of a Rotate. Rows of
+// further partitions go to the default partition, so that a key such as
+// PartitionByHost, whose values come from clients, does not open a file per
+// value.
+const maxPartitions = 256
+
+// partitionDir returns the directory of row such as dt=2006-01-02/hour=15.
+func (c *config) partitionDir(row any) string {
+	elems := make([]string, len(c.partitionKeys))
+	for i, key := range c.partitionKeys {
+		elems[i] = escapePartition(key.Name) + "=" + escapePartition(key.Value(row))
+	}
+	return path.Join(elems...)
+}
+
+// defaultPartitionDir returns the directory whose values are all
+// __HIVE_DEFAULT_PARTITION__.
+func (c *config) defaultPartitionDir() string {
+	elems := make([]string, len(c.partitionKeys))
+	for i, key := range c.partitionKeys {
+		elems[i] = escapePartition(key.Name) + "=" + escapePartition("")
+	}
+	return path.Join(elems...)
+}
+
+// escapePartition escapes a path element in the same way as Hive.
+func escapePartition(s string) string {
+	if s == "" {
+		return "__HIVE_DEFAULT_PARTITION__"
+	}
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_', c == '.':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+type partFile[T any] struct {
+	name string
+	out  io.WriteCloser
+	rowFile[T]
+}
+
+// exportPartitions reads rows from the finished tempfile f and writes them
+// into a file per partition.
+func (pl *GenericLogger[T]) exportPartitions(req exportRequest, f *os.File, dropped int64) error {
+	parts := make(map[string]*partFile[T])
+	abortAll := func() {
+		for _, p := range parts {
+			abort(p.out)
+		}
+	}
+
+	r := parquet.NewGenericReader[T](f, pl.schema)
+	defer r.Close()
+	buf := make([]T, 256)
+	for {
+		n, err := r.Read(buf)
+		for i := range buf[:n] {
+			row := &buf[i]
+			dir := pl.cfg.partitionDir(row)
+			p, ok := parts[dir]
+			if !ok && len(parts) >= maxPartitions {
+				dir = pl.cfg.defaultPartitionDir()
+				p, ok = parts[dir]
+			}
+			if !ok {
+				name := path.Join(dir, fmt.Sprintf("part-%s-%d.parquet", escapePartition(pl.cfg.labels.Instance), pl.seq.Add(1)))
+				out, err := req.openPart(name)
+				if err != nil {
+					abortAll()
+					return fmt.Errorf("Failed to create %s: %w", name, err)
+				}
+				p = &partFile[T]{
+					name:    name,
+					out:     out,
+					rowFile: newRowFile[T](&pl.cfg, pl.schema, ctxWriter{ctx: req.ctx, w: out}),
+				}
+				parts[dir] = p
+			}
+			if _, err := p.write(buf[i : i+1]); err != nil {
+				abortAll()
+				return fmt.Errorf("Failed to write %s: %w", p.name, err)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			abortAll()
+			return fmt.Errorf("Failed to read tempfile: %w", err)
+		}
+	}
+
+	dirs := make([]string, 0, len(parts))
+	for dir := range parts {
+		dirs = append(dirs, dir)
+	}
+	slices.Sort(dirs)
+	// Finish all files before committing any, so that a failure to write
+	// commits nothing.
+	for _, dir := range dirs {
+		p := parts[dir]
+		setMetadata(p.w, pl.meta, p.stats, dropped)
+		if err := p.w.Close(); err != nil {
+			abortAll()
+			return fmt.Errorf("Failed to close %s: %w", p.name, err)
+		}
+	}
+	for i, dir := range dirs {
+		p := parts[dir]
+		if err := p.out.Close(); err != nil {
+			for _, rest := range dirs[i+1:] {
+				abort(parts[rest].out)
+			}
+			return fmt.Errorf("Failed to close %s after %d of %d partitions were committed: %w", p.name, i, len(dirs), err)
+		}
+		pl.cfg.logger.Info("Succeed to export", "filename", p.name)
+	}
+	return nil
+}
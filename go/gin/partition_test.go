@@ -0,0 +1,108 @@
+package gin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestHiveLayout(t *testing.T) {
+	dir := t.TempDir()
+	pl := NewLogger(
+		WithRotateDir(dir),
+		WithLabels(Labels{Instance: "app1"}),
+		WithHiveLayout(PartitionByDate(), PartitionByHour(), PartitionByLabel("env", "prod")),
+	)
+	defer pl.Close()
+
+	start := time.Date(2026, 10, 16, 13, 59, 59, 0, time.UTC)
+	sendAndWait(pl,
+		RowType{StartTime: start},
+		RowType{StartTime: start.Add(time.Second)},
+		RowType{StartTime: start.Add(2 * time.Second)},
+	)
+	if err := pl.Rotate(); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+
+	for pattern, want := range map[string]int{
+		"dt=2026-10-16/hour=13/env=prod/part-app1-*.parquet": 1,
+		"dt=2026-10-16/hour=14/env=prod/part-app1-*.parquet": 2,
+	} {
+		files, _ := filepath.Glob(filepath.Join(dir, pattern))
+		if len(files) != 1 {
+			t.Fatalf("%s: got %v", pattern, files)
+		}
+		rows, err := readParquetFile(files[0])
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", files[0], err)
+		}
+		if len(rows) != want {
+			t.Errorf("%s: got %d rows, want %d", files[0], len(rows), want)
+		}
+	}
+}
+
+func TestEscapePartition(t *testing.T) {
+	for in, want := range map[string]string{
+		"example.com":    "example.com",
+		"localhost:8080": "localhost%3A8080",
+		"a/b=c":          "a%2Fb%3Dc",
+		"":               "__HIVE_DEFAULT_PARTITION__",
+	} {
+		if got := escapePartition(in); got != want {
+			t.Errorf("escapePartition(%q): got %q, want %q", in, got, want)
+		}
+	}
+}
+
+func readParquetFile(filename string) ([]RowType, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return parquet.Read[RowType](f, st.Size())
+}
+
+func TestMaxPartitions(t *testing.T) {
+	files := make(map[string]*bufferCloser)
+	pl := NewLogger(
+		WithHiveLayout(PartitionByHost()),
+		WithWriterFactory(func(_ context.Context, name string) (io.WriteCloser, error) {
+			files[name] = &bufferCloser{}
+			return files[name], nil
+		}),
+	)
+	defer pl.Close()
+
+	for i := range maxPartitions + 10 {
+		sendAndWait(pl, RowType{Host: fmt.Sprintf("host%d", i)})
+	}
+	if err := pl.Rotate(); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	if len(files) != maxPartitions+1 {
+		t.Errorf("got %d files, want %d", len(files), maxPartitions+1)
+	}
+	for name, out := range files {
+		if strings.HasPrefix(name, "host=__HIVE_DEFAULT_PARTITION__/") {
+			if n := countRows(t, out.Bytes()); n != 10 {
+				t.Errorf("got %d rows in the default partition, want 10", n)
+			}
+			return
+		}
+	}
+	t.Error("No file in the default partition")
+}
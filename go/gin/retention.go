@@ -0,0 +1,188 @@
+package gin
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Retention limits files in the rotate directory, which must be set by
+// WithRotateDir. Zero values mean no limit. Only paths which Rotate of the
+// Logger writes are looked at: log-*.parquet in the rotate directory and
+// its runtime subdirectory, and part-<instance>-*.parquet in the partitions
+// of WithHiveLayout. Newest files are kept first.
+type Retention struct {
+	// MaxFiles keeps the newest MaxFiles files.
+	MaxFiles int
+	// MaxAge deletes files modified before MaxAge ago.
+	MaxAge time.Duration
+	// MaxBytes keeps the newest files whose total size fits in MaxBytes.
+	MaxBytes int64
+	// DryRun logs files instead of deleting them. They are still counted
+	// in RetentionStats.
+	DryRun bool
+}
+
+func (r Retention) enabled() bool {
+	return r.MaxFiles > 0 || r.MaxAge > 0 || r.MaxBytes > 0
+}
+
+// RetentionStats are counters of the retention enforcement.
+type RetentionStats struct {
+	Runs         int64
+	FilesDeleted int64
+	BytesDeleted int64
+	Errors       int64
+}
+
+type retentionCounters struct {
+	runs, files, bytes, errors atomic.Int64
+}
+
+// RetentionStats returns counters of the retention enforcement.
+func (pl *GenericLogger[T]) RetentionStats() RetentionStats {
+	c := &pl.retention
+	return RetentionStats{
+		Runs:         c.runs.Load(),
+		FilesDeleted: c.files.Load(),
+		BytesDeleted: c.bytes.Load(),
+		Errors:       c.errors.Load(),
+	}
+}
+
+// runRetention enforces the retention every time it is triggered.
+func (pl *GenericLogger[T]) runRetention() {
+	for {
+		select {
+		case <-pl.retentionCh:
+			pl.enforceRetention(time.Now())
+		case <-pl.quitCh:
+			return
+		}
+	}
+}
+
+// triggerRetention starts the enforcement in background unless it is
+// already pending.
+func (pl *GenericLogger[T]) triggerRetention() {
+	if pl.retentionCh == nil {
+		return
+	}
+	select {
+	case pl.retentionCh <- struct{}{}:
+	default:
+	}
+}
+
+type retainedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (pl *GenericLogger[T]) enforceRetention(now time.Time) {
+	r := pl.cfg.retention
+	c := &pl.retention
+	c.runs.Add(1)
+
+	root := pl.cfg.rotateDir
+	var files []retainedFile
+	if err := pl.listRotated(root, pl.cfg.partitionKeys, true, &files); err != nil {
+		c.errors.Add(1)
+		pl.reportError(err)
+		return
+	}
+	slices.SortFunc(files, func(a, b retainedFile) int {
+		return b.modTime.Compare(a.modTime)
+	})
+
+	var total int64
+	for i, f := range files {
+		total += f.size
+		keep := (r.MaxFiles <= 0 || i < r.MaxFiles) &&
+			(r.MaxAge <= 0 || now.Sub(f.modTime) <= r.MaxAge) &&
+			(r.MaxBytes <= 0 || total <= r.MaxBytes)
+		if keep {
+			continue
+		}
+		if r.DryRun {
+			pl.cfg.logger.Info("Would delete by retention", "filename", f.path, "size", f.size)
+		} else {
+			if err := os.Remove(f.path); err != nil {
+				c.errors.Add(1)
+				pl.reportError(err)
+				continue
+			}
+			pl.dirMu.Lock()
+			removeEmptyDirs(filepath.Dir(f.path), root)
+			pl.dirMu.Unlock()
+			pl.cfg.logger.Info("Deleted by retention", "filename", f.path, "size", f.size)
+		}
+		c.files.Add(1)
+		c.bytes.Add(f.size)
+	}
+}
+
+// listRotated appends files which Rotate writes in dir to files. keys are
+// the partition keys of the levels below dir. Directories and files which
+// cannot be read for permission are skipped.
+func (pl *GenericLogger[T]) listRotated(dir string, keys []PartitionKey, root bool, files *[]retainedFile) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrPermission) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	partPrefix := "part-" + escapePartition(pl.cfg.labels.Instance) + "-"
+	for _, e := range entries {
+		name := e.Name()
+		path := filepath.Join(dir, name)
+		if root && e.IsDir() && name == runtimeSampleDir {
+			if err := pl.listRotated(path, nil, true, files); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(keys) > 0 {
+			if e.IsDir() && strings.HasPrefix(name, escapePartition(keys[0].Name)+"=") {
+				if err := pl.listRotated(path, keys[1:], false, files); err != nil {
+					return err
+				}
+			}
+			if !root {
+				continue
+			}
+		}
+		if !e.Type().IsRegular() || !strings.HasSuffix(name, ".parquet") {
+			continue
+		}
+		if root && !strings.HasPrefix(name, "log-") ||
+			!root && !strings.HasPrefix(name, partPrefix) {
+			continue
+		}
+		info, err := e.Info()
+		if errors.Is(err, fs.ErrPermission) || errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		*files = append(*files, retainedFile{path: path, size: info.Size(), modTime: info.ModTime()})
+	}
+	return nil
+}
+
+// removeEmptyDirs removes dir and its parents up to root while they are
+// empty. The caller holds dirMu.
+func removeEmptyDirs(dir, root string) {
+	for dir != root && strings.HasPrefix(dir, root) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
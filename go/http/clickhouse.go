@@ -0,0 +1,229 @@
+package http
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// ClickHouseFormat is the input format of ClickHouseSink.
+type ClickHouseFormat int
+
+const (
+	// ClickHouseParquet sends rows as a parquet file.
+	ClickHouseParquet ClickHouseFormat = iota
+	// ClickHouseRowBinary sends rows in the RowBinary format.
+	ClickHouseRowBinary
+)
+
+// ClickHouseConfig defines where and how ClickHouseSink inserts rows.
+type ClickHouseConfig struct {
+	// Endpoint is the URL of the HTTP interface. The default is
+	// http://localhost:8123/.
+	Endpoint string
+	// Table is the table to insert into, such as db.logs. It should be
+	// created by ClickHouseDDL. The default is logs.
+	Table string
+	// Format is the input format of INSERT. The default is ClickHouseParquet.
+	Format ClickHouseFormat
+	// User and Password authenticate requests.
+	User     string
+	Password string
+	// BatchConfig defines batching and retries. The default MaxBatchRows
+	// is 10000.
+	BatchConfig
+}
+
+// clickHouseColumns are the columns of RowType in ClickHouse.
+var clickHouseColumns = []struct{ name, typ string }{
+	{"StartTime", "DateTime64(9, 'UTC')"},
+	{"Latency", "Int64"},
+	{"Protocol", "LowCardinality(String)"},
+	{"Scheme", "LowCardinality(String)"},
+	{"RemoteAddr", "String"},
+	{"Host", "LowCardinality(String)"},
+	{"Method", "LowCardinality(String)"},
+	{"URL", "String"},
+	{"Pattern", "LowCardinality(String)"},
+	{"Status", "Int64"},
+	{"RequestSize", "Int64"},
+	{"ResponseSize", "Int64"},
+	{"RequestHeaders", "Map(String, Array(String))"},
+	{"ResponseHeaders", "Map(String, Array(String))"},
+	{"Error", "Nullable(String)"},
+	{"Instance", "LowCardinality(String)"},
+	{"Service", "LowCardinality(String)"},
+	{"Version", "LowCardinality(String)"},
+	{"Environment", "LowCardinality(String)"},
+	{"Goroutines", "Nullable(Int64)"},
+	{"HeapInUse", "Nullable(Int64)"},
+	{"GCCycleStart", "Nullable(Int64)"},
+	{"GCCycleEnd", "Nullable(Int64)"},
+}
+
+// ClickHouseDDL returns CREATE TABLE of table whose columns match RowType.
+func ClickHouseDDL(table string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", table)
+	for i, c := range clickHouseColumns {
+		fmt.Fprintf(&b, "  %s %s", c.name, c.typ)
+		if i < len(clickHouseColumns)-1 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(") ENGINE = MergeTree\nPARTITION BY toDate(StartTime)\nORDER BY (Instance, StartTime)")
+	return b.String()
+}
+
+// ClickHouseSink inserts rows into a table of ClickHouse over its HTTP
+// interface. Requests are sent in background.
+type ClickHouseSink struct {
+	cfg   ClickHouseConfig
+	query string
+	q     *batchQueue[RowType]
+}
+
+var _ Sink[RowType] = (*ClickHouseSink)(nil)
+
+// NewClickHouseSink returns a Sink which inserts rows into ClickHouse.
+func NewClickHouseSink(cfg ClickHouseConfig) *ClickHouseSink {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "http://localhost:8123/"
+	}
+	if cfg.Table == "" {
+		cfg.Table = "logs"
+	}
+	cfg.setDefaults(10000)
+	names := make([]string, len(clickHouseColumns))
+	for i, c := range clickHouseColumns {
+		names[i] = c.name
+	}
+	format := "Parquet"
+	if cfg.Format == ClickHouseRowBinary {
+		format = "RowBinary"
+	}
+	s := &ClickHouseSink{
+		cfg:   cfg,
+		query: fmt.Sprintf("INSERT INTO %s (%s) FORMAT %s", cfg.Table, strings.Join(names, ", "), format),
+	}
+	s.q = newBatchQueue(&s.cfg.BatchConfig, s.send)
+	return s
+}
+
+// Write implements Sink.
+func (s *ClickHouseSink) Write(rows []RowType) error {
+	for _, row := range rows {
+		if err := s.q.add(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush queues the pending rows to be sent.
+func (s *ClickHouseSink) Flush() error {
+	return s.q.flush()
+}
+
+// Close sends the pending rows and waits for queued requests.
+func (s *ClickHouseSink) Close() error {
+	return s.q.close()
+}
+
+func (s *ClickHouseSink) send(batch []RowType) error {
+	var body bytes.Buffer
+	if s.cfg.Format == ClickHouseRowBinary {
+		for i := range batch {
+			appendRowBinary(&body, &batch[i])
+		}
+	} else {
+		w := parquet.NewGenericWriter[RowType](&body, new(config).writerOptions(parquet.SchemaOf(new(RowType)))...)
+		if _, err := w.Write(batch); err != nil {
+			return fmt.Errorf("Failed to write parquet: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("Failed to close parquet writer: %w", err)
+		}
+	}
+	endpoint := s.cfg.Endpoint + "?" + url.Values{"query": {s.query}}.Encode()
+	err := s.cfg.send(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+		if s.cfg.User != "" {
+			req.Header.Set("X-ClickHouse-User", s.cfg.User)
+			req.Header.Set("X-ClickHouse-Key", s.cfg.Password)
+		}
+		return req, nil
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to insert %d rows into %s: %w", len(batch), s.cfg.Table, err)
+	}
+	return nil
+}
+
+// appendRowBinary appends row in the RowBinary format of clickHouseColumns.
+func appendRowBinary(b *bytes.Buffer, row *RowType) {
+	putInt64 := func(n int64) {
+		b.Write(binary.LittleEndian.AppendUint64(nil, uint64(n)))
+	}
+	putString := func(s string) {
+		b.Write(binary.AppendUvarint(nil, uint64(len(s))))
+		b.WriteString(s)
+	}
+	putHeaders := func(h map[string][]string) {
+		keys := make([]string, 0, len(h))
+		for k := range h {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		b.Write(binary.AppendUvarint(nil, uint64(len(keys))))
+		for _, k := range keys {
+			putString(k)
+			b.Write(binary.AppendUvarint(nil, uint64(len(h[k]))))
+			for _, v := range h[k] {
+				putString(v)
+			}
+		}
+	}
+	putInt64(row.StartTime.UnixNano())
+	putInt64(int64(row.Latency))
+	putString(row.Protocol)
+	putString(row.Scheme)
+	putString(row.RemoteAddr)
+	putString(row.Host)
+	putString(row.Method)
+	putString(row.URL)
+	putString(row.Pattern)
+	putInt64(int64(row.Status))
+	putInt64(row.RequestSize)
+	putInt64(row.ResponseSize)
+	putHeaders(row.RequestHeaders)
+	putHeaders(row.ResponseHeaders)
+	if row.Error == nil {
+		b.WriteByte(1)
+	} else {
+		b.WriteByte(0)
+		putString(*row.Error)
+	}
+	putString(row.Instance)
+	putString(row.Service)
+	putString(row.Version)
+	putString(row.Environment)
+	for _, n := range []*int64{row.Goroutines, row.HeapInUse, row.GCCycleStart, row.GCCycleEnd} {
+		if n == nil {
+			b.WriteByte(1)
+		} else {
+			b.WriteByte(0)
+			putInt64(*n)
+		}
+	}
+}
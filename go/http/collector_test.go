@@ -0,0 +1,175 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// collectorStub is a collector which drops the first connections, and
+// rejects rows of Status 400.
+type collectorStub struct {
+	ln    net.Listener
+	drops int
+
+	mu       sync.Mutex
+	rows     []RowType
+	commands []string
+	wg       sync.WaitGroup
+}
+
+func newCollectorStub(t *testing.T, drops int) *collectorStub {
+	t.Helper()
+	ln, err := net.Listen("unix", filepath.Join(t.TempDir(), "collector.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &collectorStub{ln: ln, drops: drops}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+func (s *collectorStub) close() {
+	s.ln.Close()
+	s.wg.Wait()
+}
+
+func (s *collectorStub) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			for {
+				typ, payload, err := readFrame(r)
+				if err != nil {
+					return
+				}
+				s.mu.Lock()
+				drop := s.drops > 0
+				if drop {
+					s.drops--
+				}
+				s.mu.Unlock()
+				if drop {
+					return
+				}
+				if err := s.handle(typ, payload); err != nil {
+					writeFrame(conn, collectorErr, []byte(err.Error()))
+				} else if typ == collectorRotate {
+					writeFrame(conn, collectorOK, []byte("/var/log/log.parquet"))
+				} else {
+					writeFrame(conn, collectorOK, nil)
+				}
+			}
+		}()
+	}
+}
+
+func (s *collectorStub) handle(typ byte, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch typ {
+	case collectorRows:
+		rows, err := parquet.Read[RowType](bytes.NewReader(payload), int64(len(payload)))
+		if err != nil {
+			return err
+		}
+		if rows[0].Status == 400 {
+			return errors.New("Rejected")
+		}
+		s.rows = append(s.rows, rows...)
+	case collectorExport:
+		s.commands = append(s.commands, "export "+string(payload))
+	case collectorRotate:
+		s.commands = append(s.commands, "rotate")
+	}
+	return nil
+}
+
+func TestCollectorSink(t *testing.T) {
+	stub := newCollectorStub(t, 1)
+	var errs []error
+	s := NewCollectorSink[RowType](CollectorConfig{
+		Address: stub.ln.Addr().String(),
+		BatchConfig: BatchConfig{
+			MaxBatchRows: 2,
+			RetryConfig: RetryConfig{
+				RetryInterval: time.Millisecond,
+				OnError: func(err error) {
+					errs = append(errs, err)
+				},
+			},
+		},
+	})
+	rows := []RowType{
+		{StartTime: time.Now(), Method: "GET", Status: 200},
+		{StartTime: time.Now(), Method: "POST", Status: 201},
+		{StartTime: time.Now(), Method: "GET", Status: 404},
+	}
+	if err := s.Write(rows); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write([]RowType{{Status: 400}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Export("all.parquet"); err != nil {
+		t.Error(err)
+	}
+	if path, err := s.Rotate(); err != nil || path != "/var/log/log.parquet" {
+		t.Errorf("got %s, %v, want /var/log/log.parquet", path, err)
+	}
+	stub.close()
+
+	if len(stub.rows) != len(rows) {
+		t.Fatalf("got %d rows, want %d", len(stub.rows), len(rows))
+	}
+	for i, row := range stub.rows {
+		if row.Method != rows[i].Method || row.Status != rows[i].Status {
+			t.Errorf("row %d: got %s %d, want %s %d", i, row.Method, row.Status, rows[i].Method, rows[i].Status)
+		}
+	}
+	var cerr collectorError
+	if len(errs) != 1 || !errors.As(errs[0], &cerr) {
+		t.Errorf("got errors %v, want an error of the collector", errs)
+	}
+	if len(stub.commands) != 2 || stub.commands[0] != "export all.parquet" || stub.commands[1] != "rotate" {
+		t.Errorf("got commands %v", stub.commands)
+	}
+}
+
+func TestCollectorSinkTempfile(t *testing.T) {
+	pl := NewLogger()
+	defer pl.Close()
+	pl.AddSink(NewCollectorSink[RowType](CollectorConfig{Address: filepath.Join(t.TempDir(), "collector.sock")}))
+	if err := pl.ExportTo(context.Background(), io.Discard); !errors.Is(err, ErrNoTempfile) {
+		t.Errorf("with CollectorSink only: got %v, want ErrNoTempfile", err)
+	}
+	pl.AddSink(&recordSink{})
+	if err := pl.ExportTo(context.Background(), io.Discard); err != nil {
+		t.Errorf("with another sink: got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLSink(t *testing.T) {
+	start := time.Date(2026, 10, 16, 13, 0, 0, 123456789, time.UTC)
+	errStr := "boom"
+	row := RowType{
+		StartTime:      start,
+		Latency:        1500 * time.Millisecond,
+		Protocol:       "HTTP/1.1",
+		Method:         "GET",
+		URL:            "/user/1?x=y",
+		Pattern:        "/user/{id}",
+		Status:         500,
+		Error:          &errStr,
+		RequestHeaders: map[string][]string{"Accept": {"*/*"}},
+		Instance:       "app1",
+	}
+	tests := []struct {
+		name string
+		cfg  JSONLConfig
+		want string
+	}{
+		{
+			name: "nginx",
+			want: `{"StartTime":1792155601.623,"Latency":1.500,"Protocol":"1.1","RemoteAddr":"","Host":"","Method":"GET","URL":"/user/1?x=y","Pattern":"/user/{id}","Status":500,"Error":"boom","RequestSize":0,"ResponseSize":0,"RequestHeaders":{"Accept":["*/*"]},"ResponseHeaders":null,"Instance":"app1"}`,
+		},
+		{
+			name: "rfc3339",
+			cfg:  JSONLConfig{StartTime: StartTimeRFC3339Nano, Latency: LatencyNanoseconds},
+			want: `{"StartTime":"2026-10-16T13:00:00.123456789Z","Latency":1500000000,`,
+		},
+		{
+			name: "unixnano",
+			cfg:  JSONLConfig{StartTime: StartTimeUnixNano},
+			want: `{"StartTime":1792155600123456789,"Latency":1.500,`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bufferCloser
+			s := NewJSONLSink(&buf, tt.cfg)
+			if err := s.Write([]RowType{row, {}}); err != nil {
+				t.Fatal(err)
+			}
+			if buf.Len() != 0 {
+				t.Error("Write did not buffer lines")
+			}
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if !buf.closed {
+				t.Error("Close did not close the destination")
+			}
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("got %d lines, want 2", len(lines))
+			}
+			if !strings.HasPrefix(lines[0], tt.want) {
+				t.Errorf("got %s, want %s", lines[0], tt.want)
+			}
+		})
+	}
+}
+
+// TestJSONLSinkNginx compares a row with a line which nginx/log.js writes
+// for the same request.
+func TestJSONLSinkNginx(t *testing.T) {
+	const nginx = `{"StartTime":"1792155601.623","Latency":"1.500","Protocol":"1.1","RemoteAddr":"2001:db8::1","Host":"example.com","Method":"POST","URL":"/user?x=y","Pattern":"/user","Status":201,"Error":null,"RequestSize":"180","ResponseSize":"250","RequestHeaders":{"Host":["example.com"],"Accept":["*/*"]},"ResponseHeaders":{"Content-Type":["text/plain"]},"SSL":{"Cipher":"","Ciphers":"","Curve":"","Curves":"","Protocol":"","SessionReused":""}}`
+	row := RowType{
+		StartTime:       time.Date(2026, 10, 16, 13, 0, 0, 123000000, time.UTC),
+		Latency:         1500 * time.Millisecond,
+		Protocol:        "HTTP/1.1",
+		RemoteAddr:      "[2001:db8::1]:54321",
+		Host:            "example.com",
+		Method:          "POST",
+		URL:             "/user?x=y",
+		Pattern:         "/user",
+		Status:          201,
+		RequestSize:     180,
+		ResponseSize:    250,
+		RequestHeaders:  map[string][]string{"Host": {"example.com"}, "Accept": {"*/*"}},
+		ResponseHeaders: map[string][]string{"Content-Type": {"text/plain"}},
+	}
+	var buf bufferCloser
+	s := NewJSONLSink(&buf, JSONLConfig{})
+	if err := s.Write([]RowType{row}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wantKeys, want := decodeJSONLine(t, nginx)
+	gotKeys, got := decodeJSONLine(t, buf.String())
+	wantKeys = wantKeys[:len(wantKeys)-1] // SSL is only written by nginx.
+	if strings.Join(gotKeys, " ") != strings.Join(wantKeys, " ") {
+		t.Errorf("got keys %v, want %v", gotKeys, wantKeys)
+	}
+	for _, k := range wantKeys {
+		// nginx writes numbers of variables as strings.
+		if fmt.Sprint(got[k]) != fmt.Sprint(want[k]) {
+			t.Errorf("%s: got %v, want %v", k, got[k], want[k])
+		}
+	}
+}
+
+// decodeJSONLine returns the keys of a JSON object in order and its values.
+func decodeJSONLine(t *testing.T, line string) ([]string, map[string]any) {
+	t.Helper()
+	var keys []string
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]any)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := tok.(string)
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+		values[key] = v
+	}
+	return keys, values
+}
+
+func TestMillis(t *testing.T) {
+	for ms, want := range map[int64]string{0: "0.000", 5: "0.005", 1234: "1.234", -1500: "-1.500"} {
+		if got := millis(ms); string(got) != want {
+			t.Errorf("millis(%d) = %s, want %s", ms, got, want)
+		}
+	}
+}
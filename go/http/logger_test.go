@@ -0,0 +1,192 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestLoggerLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	pl := NewLogger()
+
+	// Export may be called before the writer has started.
+	if err := pl.Export(filepath.Join(dir, "first.parquet")); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				pl.send(RowType{StartTime: time.Now()})
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 5 {
+				err := pl.Export(filepath.Join(dir, fmt.Sprintf("%d-%d.parquet", i, j)))
+				if err != nil && !errors.Is(err, ErrExportInProgress) {
+					t.Errorf("Failed to export: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := pl.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+	if err := pl.Close(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Close after Close: got %v, want ErrClosed", err)
+	}
+	if err := pl.Export(filepath.Join(dir, "closed.parquet")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Export after Close: got %v, want ErrClosed", err)
+	}
+	pl.send(RowType{})
+}
+
+func TestLoggerNotInitialized(t *testing.T) {
+	var got error
+	pl := &Logger{cfg: config{onError: func(err error) { got = err }}}
+	pl.log(RequestInfo{Method: "GET"})
+	if !errors.Is(got, ErrNotInitialized) {
+		t.Errorf("got %v, want ErrNotInitialized", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("NewGenericLogger did not panic on a nil extract")
+		}
+	}()
+	NewGenericLogger[RowType](nil)
+}
+
+func TestLabels(t *testing.T) {
+	labels := Labels{
+		Instance:    "app1",
+		Service:     "api",
+		Version:     "v1.2.3",
+		Environment: "production",
+	}
+	pl := NewLogger(WithLabels(labels))
+	defer pl.Close()
+
+	pl.log(RequestInfo{Method: "GET"})
+	sendAndWait(pl)
+	var buf bytes.Buffer
+	if err := pl.ExportTo(context.Background(), &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	rows, err := parquet.Read[RowType](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read parquet: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("got %d rows, want 1", len(rows))
+	}
+	got := Labels{
+		Instance:    rows[0].Instance,
+		Service:     rows[0].Service,
+		Version:     rows[0].Version,
+		Environment: rows[0].Environment,
+	}
+	if got != labels {
+		t.Errorf("got %+v, want %+v", got, labels)
+	}
+}
+
+type accessRow struct {
+	StartTime time.Time
+	Method    string `parquet:",dict"`
+	Status    int
+	Service   string `parquet:",dict"`
+}
+
+func TestGenericLogger(t *testing.T) {
+	pl := NewGenericLogger(func(info RequestInfo) accessRow {
+		return accessRow{
+			StartTime: info.StartTime,
+			Method:    info.Method,
+			Status:    info.Status,
+			Service:   info.Labels.Service,
+		}
+	}, WithLabels(Labels{Service: "api"}), WithSortByStartTime(2), WithBloomFilters())
+	defer pl.Close()
+
+	start := time.Now()
+	pl.log(RequestInfo{StartTime: start.Add(time.Second), Method: "POST", Status: 201})
+	pl.log(RequestInfo{StartTime: start, Method: "GET", Status: 200})
+	sendAndWait(pl)
+	var buf bytes.Buffer
+	if err := pl.ExportTo(context.Background(), &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	rows, err := parquet.Read[accessRow](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read parquet: %v", err)
+	}
+	want := []accessRow{
+		{StartTime: start, Method: "GET", Status: 200, Service: "api"},
+		{StartTime: start.Add(time.Second), Method: "POST", Status: 201, Service: "api"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i := range want {
+		if !rows[i].StartTime.Equal(want[i].StartTime) || rows[i].Method != want[i].Method ||
+			rows[i].Status != want[i].Status || rows[i].Service != want[i].Service {
+			t.Errorf("row %d: got %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestRowHooks(t *testing.T) {
+	for _, inWriter := range []bool{false, true} {
+		t.Run(fmt.Sprintf("inWriter=%v", inWriter), func(t *testing.T) {
+			var opts []Option
+			if inWriter {
+				opts = append(opts, WithHooksInWriter())
+			}
+			pl := NewLogger(opts...)
+			defer pl.Close()
+			pl.OnRow(func(row *RowType) bool {
+				return row.Method != "OPTIONS"
+			})
+			pl.OnRow(func(row *RowType) bool {
+				row.URL, _, _ = strings.Cut(row.URL, "?")
+				row.Service = "tenant-" + row.Host
+				return true
+			})
+
+			pl.log(RequestInfo{Method: "GET", Host: "a", URL: "/?token=secret"})
+			pl.log(RequestInfo{Method: "OPTIONS", Host: "b", URL: "/"})
+			sendAndWait(pl)
+			var buf bytes.Buffer
+			if err := pl.ExportTo(context.Background(), &buf); err != nil {
+				t.Fatalf("Failed to export: %v", err)
+			}
+			rows, err := parquet.Read[RowType](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatalf("Failed to read parquet: %v", err)
+			}
+			if len(rows) != 1 {
+				t.Fatalf("got %d rows, want 1", len(rows))
+			}
+			if rows[0].URL != "/" || rows[0].Service != "tenant-a" {
+				t.Errorf("got URL %q and Service %q, want / and tenant-a", rows[0].URL, rows[0].Service)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLTSVSink(t *testing.T) {
+	row := RowType{
+		StartTime:      time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC),
+		Latency:        25 * time.Millisecond,
+		RemoteAddr:     "192.0.2.1",
+		Method:         "GET",
+		URL:            "/user/1?q=a\tb",
+		Status:         200,
+		ResponseSize:   512,
+		RequestHeaders: map[string][]string{"User-Agent": {"curl/8.0"}},
+	}
+	tests := []struct {
+		name   string
+		fields []LTSVField
+		want   string
+	}{
+		{
+			name: "default",
+			want: "time:2026-10-16T13:00:00Z\thost:192.0.2.1\tmethod:GET\turi:/user/1?q=a\\tb\tstatus:200\tsize:512\treqtime:0.025\tua:curl/8.0\n",
+		},
+		{
+			name: "custom",
+			fields: []LTSVField{
+				{Label: "req", Value: func(row *RowType) string { return row.Method + " " + row.Pattern }},
+				DefaultLTSVFields()[4],
+			},
+			want: "req:GET \tstatus:200\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bufferCloser
+			s := NewLTSVSink(&buf, tt.fields)
+			if err := s.Write([]RowType{row}); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLTSVEmptyValue(t *testing.T) {
+	var buf bufferCloser
+	s := NewLTSVSink(&buf, DefaultLTSVFields()[7:])
+	s.Write([]RowType{{}})
+	s.Flush()
+	if got := buf.String(); got != "ua:-\n" {
+		t.Errorf("got %q, want %q", got, "ua:-\n")
+	}
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRetention(t *testing.T) {
+	now := time.Now()
+	for _, tt := range []struct {
+		name      string
+		retention Retention
+		want      []string
+		deleted   int64
+	}{
+		{"MaxFiles", Retention{MaxFiles: 2}, []string{"log-1.parquet", "log-2.parquet"}, 1},
+		{"MaxAge", Retention{MaxAge: 36 * time.Hour}, []string{"log-1.parquet", "log-2.parquet"}, 1},
+		{"MaxBytes", Retention{MaxBytes: 250}, []string{"log-1.parquet", "log-2.parquet"}, 1},
+		{"DryRun", Retention{MaxFiles: 1, DryRun: true}, []string{"dt=x/part-app1-3.parquet", "log-1.parquet", "log-2.parquet"}, 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			// log-1 is the newest, part-app1-3 is the oldest.
+			for i, name := range []string{"log-1.parquet", "log-2.parquet", "dt=x/part-app1-3.parquet"} {
+				filename := filepath.Join(dir, name)
+				os.MkdirAll(filepath.Dir(filename), 0o755)
+				if err := os.WriteFile(filename, make([]byte, 100), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				mtime := now.Add(-time.Duration(i) * 24 * time.Hour)
+				if err := os.Chtimes(filename, mtime, mtime); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Files which the Logger does not write are kept, even if old.
+			others := []string{"other.parquet", "app/log-0.parquet", "dt=y/part-app2-0.parquet"}
+			for _, name := range others {
+				filename := filepath.Join(dir, name)
+				os.MkdirAll(filepath.Dir(filename), 0o755)
+				if err := os.WriteFile(filename, nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				old := now.Add(-100 * 24 * time.Hour)
+				os.Chtimes(filename, old, old)
+			}
+
+			pl := NewLogger(
+				WithRotateDir(dir),
+				WithLabels(Labels{Instance: "app1"}),
+				WithHiveLayout(PartitionByDate()),
+				WithRetention(tt.retention),
+			)
+			defer pl.Close()
+			pl.enforceRetention(now)
+
+			var got []string
+			filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+				rel, _ := filepath.Rel(dir, path)
+				if err == nil && !d.IsDir() && !slices.Contains(others, filepath.ToSlash(rel)) {
+					got = append(got, filepath.ToSlash(rel))
+				}
+				return nil
+			})
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for _, name := range others {
+				if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+					t.Errorf("other file is deleted: %v", err)
+				}
+			}
+			stats := pl.RetentionStats()
+			if stats.Runs != 1 || stats.FilesDeleted != tt.deleted || stats.BytesDeleted != tt.deleted*100 {
+				t.Errorf("unexpected stats: %+v", stats)
+			}
+			if !tt.retention.DryRun {
+				if _, err := os.Stat(filepath.Join(dir, "dt=x")); !os.IsNotExist(err) {
+					t.Errorf("empty partition directory is left: %v", err)
+				}
+			}
+
+		})
+	}
+}
+
+// TestRetentionWaitsForRotate checks that an empty partition directory is
+// not removed while Rotate is creating a file in it.
+func TestRetentionWaitsForRotate(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "dt=x", "part-app1-1.parquet")
+	os.MkdirAll(filepath.Dir(filename), 0o755)
+	if err := os.WriteFile(filename, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pl := NewLogger(
+		WithRotateDir(dir),
+		WithLabels(Labels{Instance: "app1"}),
+		WithHiveLayout(PartitionByDate()),
+		WithRetention(Retention{MaxAge: time.Minute}),
+	)
+	defer pl.Close()
+
+	pl.dirMu.Lock()
+	done := make(chan struct{})
+	go func() {
+		pl.enforceRetention(time.Now().Add(time.Hour))
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if _, err := os.Stat(filepath.Dir(filename)); err != nil {
+		t.Errorf("partition directory is removed while Rotate holds it: %v", err)
+	}
+	pl.dirMu.Unlock()
+	<-done
+	if _, err := os.Stat(filepath.Dir(filename)); !os.IsNotExist(err) {
+		t.Errorf("empty partition directory is left: %v", err)
+	}
+}
+
+func TestRetentionWithoutRotateDir(t *testing.T) {
+	errCh := make(chan error, 1)
+	pl := NewLogger(WithRetention(Retention{MaxFiles: 1}), WithOnError(func(err error) { errCh <- err }))
+	defer pl.Close()
+	if err := <-errCh; !errors.Is(err, ErrNoRotateDir) {
+		t.Errorf("got %v, want ErrNoRotateDir", err)
+	}
+	if pl.retentionCh != nil {
+		t.Error("retention is enabled")
+	}
+}
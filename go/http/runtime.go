@@ -0,0 +1,108 @@
+package http
+
+import (
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RuntimeStats are values of the Go runtime around a request. Goroutines
+// and HeapInUse are read from runtime/metrics and cached for
+// runtimeStatsTTL, so that requests do not read them every time. The GC
+// cycles are read every time, since a cached value misses a GC during a
+// short request.
+type RuntimeStats struct {
+	// Goroutines is the number of goroutines at the start.
+	Goroutines int64
+	// HeapInUse is the bytes of heap objects at the start.
+	HeapInUse int64
+	// GCCycleStart and GCCycleEnd are the numbers of completed GC cycles at
+	// the start and the end. They differ if a GC completed during the
+	// request.
+	GCCycleStart int64
+	GCCycleEnd   int64
+}
+
+// runtimeStatsTTL is how long values of runtime/metrics are reused.
+const runtimeStatsTTL = 10 * time.Millisecond
+
+// runtimeSnapshot is a cached read of runtime/metrics.
+type runtimeSnapshot struct {
+	at         time.Time
+	goroutines int64
+	heapInUse  int64
+}
+
+// runtimeSampler reads runtime/metrics at most once in runtimeStatsTTL.
+type runtimeSampler struct {
+	mu      sync.Mutex
+	samples []metrics.Sample
+	last    atomic.Pointer[runtimeSnapshot]
+}
+
+var defaultRuntimeSampler runtimeSampler
+
+func (rs *runtimeSampler) read() *runtimeSnapshot {
+	now := time.Now()
+	last := rs.last.Load()
+	// If another request is reading, its values are as fresh as ours.
+	if last != nil && (now.Sub(last.at) < runtimeStatsTTL || !rs.mu.TryLock()) {
+		return last
+	}
+	if last == nil {
+		rs.mu.Lock()
+	}
+	defer rs.mu.Unlock()
+	if rs.samples == nil {
+		rs.samples = []metrics.Sample{
+			{Name: "/sched/goroutines:goroutines"},
+			{Name: "/memory/classes/heap/objects:bytes"},
+		}
+	}
+	metrics.Read(rs.samples)
+	snap := &runtimeSnapshot{
+		at:         now,
+		goroutines: sampleInt64(rs.samples[0]),
+		heapInUse:  sampleInt64(rs.samples[1]),
+	}
+	rs.last.Store(snap)
+	return snap
+}
+
+// gcCycles returns the number of completed GC cycles. Reading it alone is
+// cheap.
+func gcCycles() int64 {
+	s := [1]metrics.Sample{{Name: "/gc/cycles/total:gc-cycles"}}
+	metrics.Read(s[:])
+	return sampleInt64(s[0])
+}
+
+func sampleInt64(s metrics.Sample) int64 {
+	if s.Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return int64(s.Value.Uint64())
+}
+
+// runtimeStart returns RuntimeStats at the start of a request, or nil if
+// WithRuntimeStats is not given.
+func (pl *GenericLogger[T]) runtimeStart() *RuntimeStats {
+	if !pl.cfg.runtimeStats {
+		return nil
+	}
+	snap := defaultRuntimeSampler.read()
+	return &RuntimeStats{
+		Goroutines:   snap.goroutines,
+		HeapInUse:    snap.heapInUse,
+		GCCycleStart: gcCycles(),
+	}
+}
+
+// runtimeEnd sets values at the end of a request into stats.
+func (pl *GenericLogger[T]) runtimeEnd(stats *RuntimeStats) {
+	if stats == nil {
+		return
+	}
+	stats.GCCycleEnd = gcCycles()
+}
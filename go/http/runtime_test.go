@@ -0,0 +1,49 @@
+package http
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRuntimeStats(t *testing.T) {
+	pl := NewLogger(WithRuntimeStats())
+	defer pl.Close()
+
+	rs := pl.runtimeStart()
+	if rs == nil || rs.Goroutines <= 0 || rs.HeapInUse <= 0 {
+		t.Fatalf("got %+v, want goroutines and heap", rs)
+	}
+	// The GC cycles are not cached.
+	runtime.GC()
+	pl.runtimeEnd(rs)
+	if rs.GCCycleEnd <= rs.GCCycleStart {
+		t.Errorf("got GC cycles %d to %d, want a GC in between", rs.GCCycleStart, rs.GCCycleEnd)
+	}
+	row := DefaultExtractor(RequestInfo{Runtime: rs})
+	if row.Goroutines == nil || *row.Goroutines != rs.Goroutines || row.GCCycleEnd == nil || *row.GCCycleEnd != rs.GCCycleEnd {
+		t.Errorf("got %+v, want runtime columns of %+v", row, rs)
+	}
+
+	disabled := NewLogger()
+	defer disabled.Close()
+	if rs := disabled.runtimeStart(); rs != nil {
+		t.Errorf("got %+v without WithRuntimeStats", rs)
+	}
+	disabled.runtimeEnd(nil)
+	if row := DefaultExtractor(RequestInfo{}); row.Goroutines != nil || row.GCCycleStart != nil {
+		t.Errorf("got runtime columns %+v without WithRuntimeStats", row)
+	}
+}
+
+func TestRuntimeSamplerCache(t *testing.T) {
+	var rs runtimeSampler
+	if a, b := rs.read(), rs.read(); a != b {
+		t.Error("runtime/metrics is read again within runtimeStatsTTL")
+	}
+	a := rs.read()
+	time.Sleep(runtimeStatsTTL)
+	if b := rs.read(); a == b {
+		t.Error("runtime/metrics is not read again after runtimeStatsTTL")
+	}
+}
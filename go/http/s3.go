@@ -0,0 +1,415 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// S3Config defines where S3Uploader uploads files.
+type S3Config struct {
+	// Endpoint is the URL of an S3-compatible service, such as
+	// https://s3.ap-northeast-1.amazonaws.com or http://localhost:9000.
+	// Objects are addressed in path style.
+	Endpoint string
+	// Region is used for signing. The default is us-east-1.
+	Region string
+	Bucket string
+	// AccessKeyID, SecretAccessKey and SessionToken are the credentials.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// KeyTemplate is a text/template of object keys, executed with
+	// S3KeyData. The default is "{{.Path}}".
+	KeyTemplate string
+	// Dir is the directory which Path of S3KeyData is relative to, usually
+	// the rotate directory. If empty, Path is the base name of the file.
+	Dir string
+	// PartSize is the size of a part of multipart uploads. Files larger
+	// than PartSize are uploaded in parts. The default is 16 MiB and the
+	// minimum is 5 MiB.
+	PartSize int64
+	// DeleteLocal removes a file after its upload is confirmed.
+	DeleteLocal bool
+	// MaxQueuedFiles is the number of files waiting to be uploaded by
+	// Enqueue. The default is 64.
+	MaxQueuedFiles int
+	// UploadTimeout limits each request which uploads a file or a part,
+	// instead of the timeout of Client. The default is 5 minutes.
+	UploadTimeout time.Duration
+	RetryConfig
+}
+
+// S3KeyData is the data of the key template.
+type S3KeyData struct {
+	// Path is the slash-separated path of the file relative to Dir, such as
+	// dt=2006-01-02/part-app1-1.parquet.
+	Path string
+	// Base is the base name of the file.
+	Base string
+	// Hostname is the hostname of the process.
+	Hostname string
+	// Time is the modification time of the file in UTC.
+	Time time.Time
+}
+
+// minPartSize is the minimum size of a part except the last one.
+const minPartSize = 5 << 20
+
+// S3Uploader uploads files to an S3-compatible object storage with
+// Signature Version 4.
+// Only files passed to Enqueue or Upload are uploaded. With WithOnRotate,
+// those are files which Rotate completes in the rotate directory; files of
+// Export, ExportTo, signals and a WriterFactory are not, so call Upload for
+// them.
+type S3Uploader struct {
+	cfg   S3Config
+	key   *template.Template
+	batch BatchConfig
+	// upload is RetryConfig with a client of UploadTimeout.
+	upload RetryConfig
+	now    func() time.Time
+
+	// mu guards q and closed, since Enqueue is called by any goroutine.
+	mu     sync.Mutex
+	q      *batchQueue[string]
+	closed bool
+}
+
+// NewS3Uploader returns an uploader. It fails if KeyTemplate is invalid.
+func NewS3Uploader(cfg S3Config) (*S3Uploader, error) {
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.KeyTemplate == "" {
+		cfg.KeyTemplate = "{{.Path}}"
+	}
+	if cfg.PartSize <= 0 {
+		cfg.PartSize = 16 << 20
+	}
+	cfg.PartSize = max(cfg.PartSize, minPartSize)
+	if cfg.MaxQueuedFiles <= 0 {
+		cfg.MaxQueuedFiles = 64
+	}
+	if cfg.UploadTimeout <= 0 {
+		cfg.UploadTimeout = 5 * time.Minute
+	}
+	cfg.RetryConfig.setDefaults()
+	key, err := template.New("key").Option("missingkey=error").Parse(cfg.KeyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse KeyTemplate: %w", err)
+	}
+	u := &S3Uploader{cfg: cfg, key: key, now: time.Now}
+	client := *cfg.Client
+	client.Timeout = cfg.UploadTimeout
+	u.upload = cfg.RetryConfig
+	u.upload.Client = &client
+	u.batch = BatchConfig{MaxBatchRows: 1, MaxQueuedBatches: cfg.MaxQueuedFiles, RetryConfig: cfg.RetryConfig}
+	u.q = newBatchQueue(&u.batch, func(files []string) error {
+		return u.Upload(files[0])
+	})
+	return u, nil
+}
+
+// Enqueue uploads filename in background. Errors are reported through
+// OnError. It can be passed to WithOnRotate and is safe for concurrent use.
+func (u *S3Uploader) Enqueue(filename string) {
+	u.mu.Lock()
+	err := errUploaderClosed
+	if !u.closed {
+		err = u.q.add(filename)
+	}
+	u.mu.Unlock()
+	if err != nil {
+		u.cfg.OnError(fmt.Errorf("Failed to upload %s: %w", filename, err))
+	}
+}
+
+var errUploaderClosed = errors.New("Uploader is closed")
+
+// Close waits for queued files to be uploaded.
+func (u *S3Uploader) Close() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return nil
+	}
+	u.closed = true
+	return u.q.close()
+}
+
+// Upload uploads filename and removes it if DeleteLocal is set.
+func (u *S3Uploader) Upload(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	key, err := u.objectKey(filename, st.ModTime())
+	if err != nil {
+		return err
+	}
+	if st.Size() > u.cfg.PartSize {
+		err = u.putMultipart(key, f, st.Size())
+	} else {
+		err = u.putPart(key, nil, f, st.Size(), nil)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to upload %s to %s: %w", filename, key, err)
+	}
+	if u.cfg.DeleteLocal {
+		f.Close()
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *S3Uploader) objectKey(filename string, modTime time.Time) (string, error) {
+	data := S3KeyData{
+		Path: filepath.Base(filename),
+		Base: filepath.Base(filename),
+		Time: modTime.UTC(),
+	}
+	if u.cfg.Dir != "" {
+		rel, err := filepath.Rel(u.cfg.Dir, filename)
+		if err != nil {
+			return "", err
+		}
+		data.Path = filepath.ToSlash(rel)
+	}
+	data.Hostname, _ = os.Hostname()
+	var b strings.Builder
+	if err := u.key.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("Failed to execute KeyTemplate: %w", err)
+	}
+	return strings.TrimPrefix(b.String(), "/"), nil
+}
+
+// putPart puts the size bytes of r as the object key, or a part of it if
+// query has partNumber and uploadId. The ETag of the response is stored into
+// etag if it is not nil. r is read once to hash and again for every request,
+// so that the part is not held in memory.
+func (u *S3Uploader) putPart(key string, query url.Values, r io.ReaderAt, size int64, etag *string) error {
+	md5Hash, sha256Hash := md5.New(), sha256.New()
+	if n, err := io.Copy(io.MultiWriter(md5Hash, sha256Hash), io.NewSectionReader(r, 0, size)); err != nil {
+		return err
+	} else if n != size {
+		return io.ErrUnexpectedEOF
+	}
+	contentMD5 := base64.StdEncoding.EncodeToString(md5Hash.Sum(nil))
+	payloadHash := hex.EncodeToString(sha256Hash.Sum(nil))
+	return u.upload.send(func() (*http.Request, error) {
+		var body io.Reader = http.NoBody
+		if size > 0 {
+			body = io.NewSectionReader(r, 0, size)
+		}
+		req, err := u.newRequest(http.MethodPut, key, query, body)
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = size
+		req.Header.Set("Content-Md5", contentMD5)
+		u.sign(req, payloadHash)
+		return req, nil
+	}, func(resp *http.Response) error {
+		if etag != nil {
+			*etag = resp.Header.Get("ETag")
+		}
+		return nil
+	})
+}
+
+type s3Part struct {
+	PartNumber int
+	ETag       string
+}
+
+func (u *S3Uploader) putMultipart(key string, f io.ReaderAt, size int64) error {
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err := u.do(http.MethodPost, key, url.Values{"uploads": {""}}, nil, func(resp *http.Response) error {
+		return xml.NewDecoder(resp.Body).Decode(&initiated)
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to initiate multipart upload: %w", err)
+	}
+	if initiated.UploadID == "" {
+		return errors.New("Failed to initiate multipart upload: No UploadId")
+	}
+	var parts []s3Part
+	for offset := int64(0); offset < size; offset += u.cfg.PartSize {
+		part := s3Part{PartNumber: len(parts) + 1}
+		query := url.Values{"partNumber": {strconv.Itoa(part.PartNumber)}, "uploadId": {initiated.UploadID}}
+		n := min(u.cfg.PartSize, size-offset)
+		if err := u.putPart(key, query, io.NewSectionReader(f, offset, n), n, &part.ETag); err != nil {
+			u.do(http.MethodDelete, key, url.Values{"uploadId": {initiated.UploadID}}, nil, nil)
+			return fmt.Errorf("Failed to upload part %d: %w", part.PartNumber, err)
+		}
+		parts = append(parts, part)
+	}
+	complete, _ := xml.Marshal(struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []s3Part `xml:"Part"`
+	}{Parts: parts})
+	err = u.do(http.MethodPost, key, url.Values{"uploadId": {initiated.UploadID}}, complete, func(resp *http.Response) error {
+		// An error may be returned with 200 OK after the upload started.
+		var result struct {
+			XMLName xml.Name
+			Message string
+		}
+		if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil && err != io.EOF {
+			return err
+		}
+		if result.XMLName.Local == "Error" {
+			return errors.New(result.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		u.do(http.MethodDelete, key, url.Values{"uploadId": {initiated.UploadID}}, nil, nil)
+		return fmt.Errorf("Failed to complete multipart upload: %w", err)
+	}
+	return nil
+}
+
+// do sends a signed request with retries.
+func (u *S3Uploader) do(method, key string, query url.Values, body []byte, handle func(*http.Response) error) error {
+	return u.cfg.send(func() (*http.Request, error) {
+		req, err := u.newRequest(method, key, query, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		u.sign(req, sha256Hex(body))
+		return req, nil
+	}, handle)
+}
+
+func (u *S3Uploader) newRequest(method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	rawURL := strings.TrimSuffix(u.cfg.Endpoint, "/") + "/" + awsURIEncode(u.cfg.Bucket, true) + "/" + awsURIEncode(key, false)
+	if len(query) > 0 {
+		rawURL += "?" + canonicalQuery(query)
+	}
+	return http.NewRequest(method, rawURL, body)
+}
+
+// sign signs req with Signature Version 4. payloadHash is the hex SHA-256
+// of the body. Every header of req is signed.
+func (u *S3Uploader) sign(req *http.Request, payloadHash string) {
+	now := u.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if u.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", u.cfg.SessionToken)
+	}
+	scope := amzDate[:8] + "/" + u.cfg.Region + "/s3/aws4_request"
+	signedHeaders, canonicalHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	key := []byte("AWS4" + u.cfg.SecretAccessKey)
+	for _, s := range []string{amzDate[:8], u.cfg.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		u.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalHeaders returns the signed headers and the canonical headers
+// of req, which include Host and every header of req.
+func canonicalHeaders(req *http.Request) (string, string) {
+	headers := map[string]string{"host": req.URL.Host}
+	for k, vs := range req.Header {
+		values := make([]string, len(vs))
+		for i, v := range vs {
+			values[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[strings.ToLower(k)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	var b strings.Builder
+	for _, k := range names {
+		b.WriteString(k + ":" + headers[k] + "\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var params []string
+	for _, k := range keys {
+		values := slices.Clone(query[k])
+		slices.Sort(values)
+		for _, v := range values {
+			params = append(params, awsURIEncode(k, true)+"="+awsURIEncode(v, true))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// awsURIEncode encodes s as Signature Version 4 requires. Slashes are kept
+// unless encodeSlash is set.
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
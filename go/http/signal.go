@@ -0,0 +1,136 @@
+package http
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SignalConfig defines which signals HandleSignals listens to.
+type SignalConfig struct {
+	// Path is the base filename of exported files. The time of the export
+	// is inserted before the extension in milliseconds, e.g.
+	// /tmp/log-20060102-150405.000.parquet.
+	// The default is log.parquet in os.TempDir().
+	Path string
+	// Export exports rows collected so far to Path. The default is SIGUSR1,
+	// or none on Windows.
+	Export os.Signal
+	// Rotate calls Logger.Rotate. The default is SIGHUP, or none on Windows.
+	Rotate os.Signal
+	// Flush exports rows collected so far to Path and stops handling
+	// signals. On unix, the signal is raised again so that the default
+	// action or another handler still takes place. On Windows, a signal
+	// cannot be raised again, so the process exits with the exit code of
+	// Ctrl-C instead. The default is SIGTERM, or os.Interrupt on Windows.
+	Flush os.Signal
+	// OnError is called when an action fails. The default reports the
+	// error in the same way as other errors of the Logger.
+	OnError func(error)
+}
+
+func (cfg *SignalConfig) setDefaults() {
+	if cfg.Path == "" {
+		cfg.Path = filepath.Join(os.TempDir(), "log.parquet")
+	}
+	if cfg.Export == nil {
+		cfg.Export = defaultExportSignal
+	}
+	if cfg.Rotate == nil {
+		cfg.Rotate = defaultRotateSignal
+	}
+	if cfg.Flush == nil {
+		cfg.Flush = defaultFlushSignal
+	}
+}
+
+// HandleSignals starts a goroutine which maps signals to Logger actions.
+// It stops when ctx is done or after the flush signal is handled.
+func (pl *GenericLogger[T]) HandleSignals(ctx context.Context, cfg SignalConfig) {
+	cfg.setDefaults()
+	if cfg.OnError == nil {
+		cfg.OnError = pl.reportError
+	}
+	var signals []os.Signal
+	for _, s := range []os.Signal{cfg.Export, cfg.Rotate, cfg.Flush} {
+		if s != nil {
+			signals = append(signals, s)
+		}
+	}
+	if len(signals) == 0 {
+		return
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, signals...)
+	go func() {
+		defer signal.Stop(sig)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case s := <-sig:
+				if !pl.handleSignal(cfg, s, func() { signal.Stop(sig) }) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// handleSignal runs the action of s and reports whether signals are still
+// handled. stop stops handling signals before the flush signal is raised
+// again.
+func (pl *GenericLogger[T]) handleSignal(cfg SignalConfig, s os.Signal, stop func()) bool {
+	switch s {
+	case cfg.Export:
+		if err := pl.Export(timestampedName(cfg.Path, time.Now())); err != nil {
+			cfg.OnError(err)
+		}
+	case cfg.Rotate:
+		if err := pl.Rotate(); err != nil {
+			cfg.OnError(err)
+		}
+	case cfg.Flush:
+		if err := pl.Export(timestampedName(cfg.Path, time.Now())); err != nil {
+			cfg.OnError(err)
+		}
+		stop()
+		if err := raiseSignal(s); err != nil {
+			cfg.OnError(err)
+		}
+		return false
+	}
+	return true
+}
+
+// exit is os.Exit, which is replaced in tests.
+var exit = os.Exit
+
+// exitCodeInterrupted is STATUS_CONTROL_C_EXIT of Windows as an int32.
+const exitCodeInterrupted = -1073741510
+
+// reraise raises s again, so that its default action takes place.
+func reraise(s os.Signal) error {
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return err
+	}
+	return p.Signal(s)
+}
+
+// exitInterrupted exits as the default action of Ctrl-C does, since a
+// signal cannot be raised again on Windows.
+func exitInterrupted(os.Signal) error {
+	exit(exitCodeInterrupted)
+	return nil
+}
+
+// timestampedName inserts t in milliseconds before the extension of path,
+// so that exports in the same second do not overwrite each other.
+func timestampedName(path string, t time.Time) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + t.Format("20060102-150405.000") + ext
+}
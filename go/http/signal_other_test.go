@@ -0,0 +1,16 @@
+//go:build !unix
+
+package http
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSignalDefaults(t *testing.T) {
+	var cfg SignalConfig
+	cfg.setDefaults()
+	if cfg.Export != nil || cfg.Rotate != nil || cfg.Flush != os.Interrupt {
+		t.Errorf("got %v, %v and %v, want only os.Interrupt to flush", cfg.Export, cfg.Rotate, cfg.Flush)
+	}
+}
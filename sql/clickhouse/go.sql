@@ -1,10 +1,37 @@
 --
 -- $ cat parquet_clickhouse.sql | clickhouse > result.md
 --
-CREATE VIEW IF NOT EXISTS logs AS SELECT * FROM file('/tmp/log.parquet');
+CREATE VIEW IF NOT EXISTS logs AS SELECT * FROM file('/tmp/log-*.parquet');
 
 SELECT '# ' || formatDateTime(min(StartTime), '%Y-%m-%d %H:%i:%S') || ' - ' || formatDateTime(max(StartTime + Latency/1e9), '%Y-%m-%d %H:%i:%S') FROM logs FORMAT LineAsString;
 
+-- ClickHouse does not read key/value metadata of parquet, so it is looked up
+-- in the footer of each file, where a key/value pair is 0x18 <len> key 0x18
+-- <len> value and <len> is a varint. Only lengths of one or two bytes are
+-- decoded, so values of 16384 bytes or more are not shown. Counts are summed
+-- over the files and other values are listed once like duckdb/go.sql.
+SELECT '' FORMAT LineAsString;
+
+SELECT '- ' || key || ': ' || if(
+  key IN ('row_count', 'dropped_count'),
+  toString(sum(toInt64OrZero(value))),
+  arrayStringConcat(arraySort(groupUniqArray(value)), ', ')
+)
+FROM (
+  SELECT key, substring(footer, start + if(b0 < 128, 1, 2), if(b0 < 128, b0, b0 - 128 + 128 * b1)) AS value
+  FROM (
+    SELECT key, footer, start, reinterpretAsUInt8(substring(footer, start, 1)) AS b0, reinterpretAsUInt8(substring(footer, start + 1, 1)) AS b1
+    FROM (
+      SELECT key, footer, position(footer, '\x18' || char(length(key)) || key || '\x18') + length(key) + 3 AS start
+      FROM (
+        SELECT substring(raw_blob, length(raw_blob) - 7 - reinterpretAsUInt32(substring(raw_blob, -8, 4)), reinterpretAsUInt32(substring(raw_blob, -8, 4))) AS footer
+        FROM file('/tmp/log-*.parquet', 'RawBLOB')
+      )
+      ARRAY JOIN ['adapter', 'dropped_count', 'go_version', 'hostname', 'module_version', 'pid', 'row_count'] AS key
+    ) WHERE start > length(key) + 3
+  )
+) GROUP BY key ORDER BY key FORMAT LineAsString;
+
 SELECT '\n## By Count\n' FORMAT LineAsString;
 
 SELECT
@@ -19,6 +46,18 @@ SELECT
   Method, Pattern
 FROM logs GROUP BY ALL ORDER BY cnt DESC LIMIT 40 FORMAT Markdown;
 
+SELECT '\n## By Instance\n' FORMAT LineAsString;
+
+SELECT
+  round(100 * count() / sum(count()) OVER (), 3) AS "cum%",
+  count() AS cnt,
+  count(CASE WHEN Status BETWEEN 500 AND 599 THEN 1 END) AS 5xx,
+  round(sum(Latency)/1e9, 3) AS sum,
+  round(avg(Latency)/1e9, 3) AS avg,
+  round(quantile(0.99)(Latency)/1e9, 3) AS p99,
+  Instance, Service, Version, Environment
+FROM logs GROUP BY ALL ORDER BY cnt DESC LIMIT 40 FORMAT Markdown;
+
 SELECT '\n## By Latency\n' FORMAT LineAsString;
 
 SELECT
@@ -0,0 +1,31 @@
+--
+-- Table for ClickHouseSink, generated by ClickHouseDDL("logs").
+-- Query it with go.sql after removing CREATE VIEW.
+--
+CREATE TABLE IF NOT EXISTS logs (
+  StartTime DateTime64(9, 'UTC'),
+  Latency Int64,
+  Protocol LowCardinality(String),
+  Scheme LowCardinality(String),
+  RemoteAddr String,
+  Host LowCardinality(String),
+  Method LowCardinality(String),
+  URL String,
+  Pattern LowCardinality(String),
+  Status Int64,
+  RequestSize Int64,
+  ResponseSize Int64,
+  RequestHeaders Map(String, Array(String)),
+  ResponseHeaders Map(String, Array(String)),
+  Error Nullable(String),
+  Instance LowCardinality(String),
+  Service LowCardinality(String),
+  Version LowCardinality(String),
+  Environment LowCardinality(String),
+  Goroutines Nullable(Int64),
+  HeapInUse Nullable(Int64),
+  GCCycleStart Nullable(Int64),
+  GCCycleEnd Nullable(Int64)
+) ENGINE = MergeTree
+PARTITION BY toDate(StartTime)
+ORDER BY (Instance, StartTime);
@@ -1,12 +1,24 @@
 --
--- $ cat parquet.sql | duckdb -cmd "SET VARIABLE path = '/tmp/log.parquet'" > result.md
+-- $ cat parquet.sql | duckdb -cmd "SET VARIABLE path = '/tmp/log-*.parquet'" > result.md
 --
-CREATE OR REPLACE TABLE logs AS FROM read_parquet(ifnull(getvariable('path'), '/tmp/log.parquet'));
+CREATE OR REPLACE TABLE logs AS FROM read_parquet(ifnull(getvariable('path'), '/tmp/log-*.parquet'));
 
 .headers off
 .mode column
 SELECT '# ' || strftime(min(StartTime), '%Y-%m-%d %H:%M:%S') || ' - ' || strftime(max(StartTime + to_microseconds((Latency/1e3)::INTEGER)), '%Y-%m-%d %H:%M:%S') FROM logs;
 
+.print ""
+
+SELECT
+  '- ' || k || ': ' || CASE
+    WHEN k IN ('row_count', 'dropped_count') THEN sum(TRY_CAST(v AS BIGINT))::VARCHAR
+    ELSE array_to_string(list_sort(list_distinct(list(v))), ', ')
+  END
+FROM (
+  SELECT decode(key) AS k, decode(value) AS v
+  FROM parquet_kv_metadata(ifnull(getvariable('path'), '/tmp/log-*.parquet'))
+) WHERE k NOT IN ('config', 'first_start_time', 'last_start_time') GROUP BY k ORDER BY k;
+
 .headers on
 .mode markdown
 
@@ -24,6 +36,18 @@ SELECT
   Method, Pattern
 FROM logs GROUP BY ALL ORDER BY cnt DESC LIMIT 40;
 
+.print "\n## By Instance\n"
+
+SELECT
+  (100 * count(*) / sum(count(*)) OVER ())::DECIMAL AS 'cum%',
+  count(*) AS cnt,
+  count(CASE WHEN Status BETWEEN 500 AND 599 THEN 1 END) AS '5xx',
+  (sum(Latency)/1e9)::DECIMAL AS sum,
+  (avg(Latency)/1e9)::DECIMAL AS avg,
+  (quantile_disc(Latency,0.99)/1e9)::DECIMAL AS p99,
+  Instance, Service, Version, Environment
+FROM logs GROUP BY ALL ORDER BY cnt DESC LIMIT 40;
+
 .print "\n## By Latency\n"
 
 SELECT
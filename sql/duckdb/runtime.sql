@@ -0,0 +1,65 @@
+--
+-- $ cat runtime.sql | duckdb -cmd "SET VARIABLE path = '/tmp/log-*.parquet'" -cmd "SET VARIABLE runtime_path = '/tmp/runtime-log-*.parquet'" > result.md
+--
+CREATE OR REPLACE TABLE logs AS FROM read_parquet(ifnull(getvariable('path'), '/tmp/log-*.parquet'));
+CREATE OR REPLACE TABLE samples AS FROM read_parquet(ifnull(getvariable('runtime_path'), '/tmp/runtime-log-*.parquet'));
+
+CREATE OR REPLACE TABLE seconds AS
+WITH r AS (
+  SELECT
+    date_trunc('second', StartTime) AS sec,
+    count(*) AS cnt,
+    quantile_disc(Latency, 0.5) AS p50,
+    quantile_disc(Latency, 0.99) AS p99,
+    max(Latency) AS max
+  FROM logs GROUP BY ALL
+), s AS (
+  SELECT
+    date_trunc('second', StartTime) AS sec,
+    sum(GCCycles) AS gc,
+    sum(GCPauses) AS pauses,
+    sum(GCPauseTotal) AS pause_sum,
+    max(GCPauseMax) AS pause_max,
+    max(SchedLatencyP99) AS sched_p99,
+    max(HeapInUse) AS heap,
+    max(Goroutines) AS goroutines,
+    sum(CPUGC) / nullif(sum(CPUTotal), 0) AS gc_cpu
+  FROM samples GROUP BY ALL
+)
+SELECT * FROM r FULL JOIN s USING (sec);
+
+.headers off
+.mode column
+SELECT '# ' || strftime(min(sec), '%Y-%m-%d %H:%M:%S') || ' - ' || strftime(max(sec), '%Y-%m-%d %H:%M:%S') FROM seconds;
+
+.headers on
+.mode markdown
+
+.print "\n## Latency with and without GC\n"
+
+SELECT
+  gc > 0 AS gc,
+  count(*) AS seconds,
+  sum(cnt) AS cnt,
+  (avg(p50)/1e9)::DECIMAL AS avg_p50,
+  (avg(p99)/1e9)::DECIMAL AS avg_p99,
+  (max(max)/1e9)::DECIMAL AS max
+FROM seconds WHERE cnt IS NOT NULL AND gc IS NOT NULL GROUP BY ALL ORDER BY gc;
+
+.print "\n## Latency vs GC per Second\n"
+
+SELECT
+  strftime(sec, '%H:%M:%S') AS time,
+  cnt,
+  (p50/1e9)::DECIMAL AS p50,
+  (p99/1e9)::DECIMAL AS p99,
+  (max/1e9)::DECIMAL AS max,
+  gc,
+  pauses,
+  (pause_sum/1e9)::DECIMAL(18,6) AS pause_sum,
+  (pause_max/1e9)::DECIMAL(18,6) AS pause_max,
+  (sched_p99/1e9)::DECIMAL(18,6) AS sched_p99,
+  (gc_cpu * 100)::DECIMAL AS 'gc_cpu%',
+  heap,
+  goroutines
+FROM seconds ORDER BY sec;